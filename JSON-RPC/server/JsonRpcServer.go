@@ -13,6 +13,11 @@ import (
 	"encoding/json"
 	"strconv"
 	"log"
+	"log/slog"
+	"io"
+	"time"
+	"sync"
+	"sync/atomic"
 )
 /*Refers to the integer structure that points to the function that is being called. It is used for
 *registering the rpc service.*/
@@ -22,30 +27,44 @@ type FileType struct{
 	File string `json:"file"`
 }
 
+/*The refers to the configuration of the log of the server.
+ * Level: The lowest level that is logged, "debug", "info" (default), "warn" or "error".
+ * Output: "stderr" (default), "stdout" or the file the log is appended to.
+ * Format: "text" (default) or "json".*/
+type LogType struct{
+	Level string `json:"level"`
+	Output string `json:"output"`
+	Format string `json:"format"`
+}
+
 /*The refers to the input JSON message structure that represents the configuration details of the server.
  * Client ID: Refers to the client ID.
  * Protocol: Refers to the protocol used to contact the remote server and is mostly TCP.
  * IPAddress: Refers to the IP address of the client.
  * Port: Refers to the port number being used to start the communication process.
  * PersistentStorageContainer: The location of the DICT3 file.
- * Methods: Contains the list of all the functions that can be called at the remote server. */
+ * Methods: Contains the list of all the functions that can be called at the remote server.
+ * Log: The configuration of the log of the server. */
 type config struct{
-	serverID string `json:"serverID"`
+	ServerID string `json:"serverID"`
 	Protocol string `json:"protocol"`
 	IpAddress string `json:"ipAddress"`
 	Port int `json:"port"`
 	PersistentStorageContainer FileType `json:"persistentStorageContainer"`
 	Methods []string `json:"methods"`
+	Log LogType `json:"log"`
 }
 
 /* The JSON message structure of the input passed to the function.
  * Method: The function to be called.
  * Params: The input json object that is passed as an argument to the function.
- * Id: The unique id refers to the transaction between the client and the server.*/
+ * Id: The unique id refers to the transaction between the client and the server.
+ * RequestID: The id the server gives the request in its log.*/
 type JsonMessage struct{
 	Method string 	`json:"method"`
 	Params []interface{} `json:"params"`
 	Id int `json:"id"`
+	RequestID string `json:"-"`
 }
 
 /* The json result structure that will be displayed to the user after the completion
@@ -107,8 +126,29 @@ type NoOutput struct {
 	Error string
 }
 
+/*This structure is used to serve a client connection. It wraps the JSON-RPC codec of the connection, gives every request an id
+*and logs it.*/
+type requestCodec struct{
+	rpc.ServerCodec
+	client string
+	lock sync.Mutex
+	seq uint64
+	pending map[uint64]*pendingrequest
+}
+
+/*This structure is used to define a request of a connection that has not been answered yet.
+* method, id: The function that is called and the id of the request.
+* start: The time the request was read. */
+type pendingrequest struct{
+	method string
+	id string
+	start time.Time
+}
+
 var serverconfig config
 var close bool
+var logger = slog.Default()
+var requestcount atomic.Uint64
 
 /*The lookUp function is used to return the value referred by an existing ID(key + relationship).
 *input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
@@ -122,13 +162,20 @@ func (d *Dict3) LookUp(input JsonMessage, output *JsonResultLookUp) error {
 	relationship := string(input.Params[1].(string))
 	if _, err := os.Stat(serverconfig.PersistentStorageContainer.File); err == nil {
 		inFile, err := os.OpenFile(serverconfig.PersistentStorageContainer.File, os.O_APPEND|os.O_CREATE,0660)
-		checkError(err)
+		if err != nil {
+			return storageerror(&input,err)
+		}
 		defer inFile.Close()
 		scanner := bufio.NewScanner(inFile)
 		var DICT3exist DICT3format
+		line := 0
 		for scanner.Scan() {
+			line++
 			err := json.Unmarshal([]byte(scanner.Text()),&DICT3exist)
-			checkError(err)
+			if err != nil {
+				badline(&input,line,err)
+				continue
+			}
 			if key == DICT3exist.Key && relationship == DICT3exist.Relationship {
 				output.Result = []interface{}{key,relationship,DICT3exist.Value}
 				return nil
@@ -156,13 +203,20 @@ func (d *Dict3) Insert(input *JsonMessage, output *JsonResultInsert) error {
 	DICT3input := DICT3format{strings.TrimSpace(string(input.Params[0].(string))),strings.TrimSpace(string(input.Params[1].(string))),map[string]interface{}(input.Params[2].(map[string]interface{}))}
 	if _, err := os.Stat(serverconfig.PersistentStorageContainer.File); err == nil {
 		inFile, err := os.OpenFile(serverconfig.PersistentStorageContainer.File, os.O_APPEND|os.O_CREATE,0660)
+		if err != nil {
+			return storageerror(input,err)
+		}
 		defer inFile.Close()
-		checkError(err)
 		scanner := bufio.NewScanner(inFile)
 		var DICT3exist DICT3format
+		line := 0
 		for scanner.Scan() {
+			line++
 			err := json.Unmarshal([]byte(scanner.Text()),&DICT3exist)
-			checkError(err)
+			if err != nil {
+				badline(input,line,err)
+				continue
+			}
 			if DICT3input.Key == DICT3exist.Key && DICT3input.Relationship == DICT3exist.Relationship {
 				return errors.New("Key error - Key and Relationship already present in DICT3. Use insertOrUpdate function to change values of existing key.")	
 			}
@@ -170,7 +224,9 @@ func (d *Dict3) Insert(input *JsonMessage, output *JsonResultInsert) error {
 		}
 	}
 	
-	saveJSON(serverconfig.PersistentStorageContainer.File,DICT3input)
+	if err := saveJSON(serverconfig.PersistentStorageContainer.File,DICT3input); err != nil {
+		return storageerror(input,err)
+	}
 	output.Result = true
 	output.Id = input.Id
 	return nil
@@ -194,13 +250,21 @@ func (d *Dict3) InsertOrUpdate(input *JsonMessage, output *NoOutput) error {
 	var lines []string
 	if _, err := os.Stat(serverconfig.PersistentStorageContainer.File); err == nil {
 		inFile, err := os.OpenFile(serverconfig.PersistentStorageContainer.File, os.O_APPEND|os.O_CREATE,0660)
-		checkError(err)
+		if err != nil {
+			return storageerror(input,err)
+		}
 		defer inFile.Close()
 		scanner := bufio.NewScanner(inFile)
 		var DICT3exist DICT3format
+		line := 0
 		for scanner.Scan() {
+			line++
 			err := json.Unmarshal([]byte(scanner.Text()),&DICT3exist)
-			checkError(err)
+			if err != nil {
+				badline(input,line,err)
+				lines = append(lines, scanner.Text())
+				continue
+			}
 			if DICT3input.Key == DICT3exist.Key && DICT3input.Relationship == DICT3exist.Relationship {
 				if reflect.DeepEqual(DICT3exist,DICT3input){
 						return errors.New("The ID is already set with the same value")
@@ -214,18 +278,24 @@ func (d *Dict3) InsertOrUpdate(input *JsonMessage, output *NoOutput) error {
 		inFile.Close()
 	}
 	if flag == false{
-		saveJSON(serverconfig.PersistentStorageContainer.File,DICT3input)
+		if err := saveJSON(serverconfig.PersistentStorageContainer.File,DICT3input); err != nil {
+			return storageerror(input,err)
+		}
 	}else{
 		os.Remove(serverconfig.PersistentStorageContainer.File)
 		outFile, err :=  os.Create(serverconfig.PersistentStorageContainer.File);
-		checkError(err)
+		if err != nil {
+			return storageerror(input,err)
+		}
 		w := bufio.NewWriter(outFile)
 		for _, line := range lines {
 			fmt.Fprintln(w, line)
 		}
 		w.Flush()
 		outFile.Close()
-		saveJSON(serverconfig.PersistentStorageContainer.File,DICT3input)
+		if err := saveJSON(serverconfig.PersistentStorageContainer.File,DICT3input); err != nil {
+			return storageerror(input,err)
+		}
 	}
 	output.Error = " "
 	return nil
@@ -245,13 +315,21 @@ func (d *Dict3) Delete(input *JsonMessage, output *NoOutput) error {
 	var lines []string
 	if _, err := os.Stat(serverconfig.PersistentStorageContainer.File); err == nil {
 		inFile, err := os.OpenFile(serverconfig.PersistentStorageContainer.File, os.O_APPEND|os.O_CREATE,0660)
-		checkError(err)
+		if err != nil {
+			return storageerror(input,err)
+		}
 		defer inFile.Close()
 		scanner := bufio.NewScanner(inFile)
 		var DICT3exist DICT3format
+		line := 0
 		for scanner.Scan() {
+			line++
 			err := json.Unmarshal([]byte(scanner.Text()),&DICT3exist)
-			checkError(err)
+			if err != nil {
+				badline(input,line,err)
+				lines = append(lines, scanner.Text())
+				continue
+			}
 			if key == DICT3exist.Key && relationship == DICT3exist.Relationship {
 				flag = true
 				continue
@@ -266,7 +344,9 @@ func (d *Dict3) Delete(input *JsonMessage, output *NoOutput) error {
 	}else{
 		os.Remove(serverconfig.PersistentStorageContainer.File)
 		outFile, err :=  os.Create(serverconfig.PersistentStorageContainer.File);
-		checkError(err)
+		if err != nil {
+			return storageerror(input,err)
+		}
 		w := bufio.NewWriter(outFile)
 		for _, line := range lines {
 			fmt.Fprintln(w, line)
@@ -287,13 +367,20 @@ func (d *Dict3) ListKeys(input *JsonMessage, output *JsonListKeys) error {
 	key := []string{}
 	if _, err := os.Stat(serverconfig.PersistentStorageContainer.File); err == nil {
 		inFile, err := os.OpenFile(serverconfig.PersistentStorageContainer.File, os.O_APPEND|os.O_CREATE,0660)
-		checkError(err)
+		if err != nil {
+			return storageerror(input,err)
+		}
 		defer inFile.Close()
 		scanner := bufio.NewScanner(inFile)
 		var DICT3exist DICT3format
+		line := 0
 		for scanner.Scan() {
+			line++
 			err := json.Unmarshal([]byte(scanner.Text()),&DICT3exist)
-			checkError(err)
+			if err != nil {
+				badline(input,line,err)
+				continue
+			}
 			keymap[DICT3exist.Key] = struct{}{}
 		}
 		for k := range(keymap){
@@ -315,13 +402,20 @@ func (d *Dict3) ListIDs(input *JsonMessage, output *JsonListIDs) error {
 	id := [][]string{}
 	if _, err := os.Stat(serverconfig.PersistentStorageContainer.File); err == nil {
 		inFile, err := os.OpenFile(serverconfig.PersistentStorageContainer.File, os.O_APPEND|os.O_CREATE,0660)
-		checkError(err)
+		if err != nil {
+			return storageerror(input,err)
+		}
 		defer inFile.Close()
 		scanner := bufio.NewScanner(inFile)
 		var DICT3exist DICT3format
+		line := 0
 		for scanner.Scan() {
+			line++
 			err := json.Unmarshal([]byte(scanner.Text()),&DICT3exist)
-			checkError(err)
+			if err != nil {
+				badline(input,line,err)
+				continue
+			}
 			tempid := []string{DICT3exist.Key,DICT3exist.Relationship}
 			id = append(id,tempid)
 		}
@@ -367,6 +461,7 @@ func main() {
 			checkError(err)
 		}
 	}
+	checkError(initlogging())
 	tcpAddr, err := net.ResolveTCPAddr("tcp", ":"+strconv.Itoa(serverconfig.Port))
 	checkError(err)
 	
@@ -374,29 +469,135 @@ func main() {
 	listener, err := net.ListenTCP("tcp", tcpAddr)
 	checkError(err)
 
+	logger.Info("the server is listening","node",serverconfig.Port,"file",serverconfig.PersistentStorageContainer.File)
+
 	for !close {
-		conn, _ := listener.Accept()
-		jsonrpc.ServeConn(conn)
+		conn, err := listener.Accept()
+		if err != nil {
+			logger.Warn("a connection could not be accepted","node",serverconfig.Port,"error",err)
+			continue
+		}
+		rpc.ServeCodec(&requestCodec{ServerCodec: jsonrpc.NewServerCodec(conn), client: conn.RemoteAddr().String(), pending: make(map[uint64]*pendingrequest)})
+	}
+}
+
+/*This function reads the header of a request and starts timing it. The requests of a connection are answered concurrently,
+*so they are kept by their sequence number. The body of the request is always read right after its header.*/
+func (c *requestCodec) ReadRequestHeader(r *rpc.Request) error {
+	if err := c.ServerCodec.ReadRequestHeader(r); err != nil {
+		return err
+	}
+	id := strconv.Itoa(serverconfig.Port)+"-"+strconv.FormatUint(requestcount.Add(1),10)
+	c.lock.Lock()
+	c.seq = r.Seq
+	c.pending[r.Seq] = &pendingrequest{strings.TrimPrefix(r.ServiceMethod,"Dict3."),id,time.Now()}
+	c.lock.Unlock()
+	return nil
+}
+
+/*This function reads the input of a request and sets the id of the request in it.*/
+func (c *requestCodec) ReadRequestBody(body interface{}) error {
+	if err := c.ServerCodec.ReadRequestBody(body); err != nil {
+		return err
+	}
+	if input, ok := body.(*JsonMessage); ok {
+		c.lock.Lock()
+		if pending, ok := c.pending[c.seq]; ok {
+			input.RequestID = pending.id
+		}
+		c.lock.Unlock()
+	}
+	return nil
+}
+
+/*This function writes the response of a request and logs it. Successful calls are logged at the debug level and failed calls
+*as warnings.*/
+func (c *requestCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	c.lock.Lock()
+	pending, ok := c.pending[r.Seq]
+	delete(c.pending,r.Seq)
+	c.lock.Unlock()
+	err := c.ServerCodec.WriteResponse(r,body)
+	if !ok {
+		return err
 	}
+	log := logger.With("node",serverconfig.Port,"request",pending.id,"method",pending.method,"client",c.client,"latency_ms",float64(time.Since(pending.start).Microseconds())/1000)
+	if len(r.Error) != 0 {
+		log.Warn("request failed","error",r.Error)
+	} else {
+		log.Debug("request")
+	}
+	return err
 }
 
 /* This function is used to update the DICT3 file with any new insertions or updations of the triplet values.
 * fileName: It refers to the file where all the changes goes and is mostly DICT3.
-* key: The data that needs to be added to the file.*/
-func saveJSON(fileName string, key interface{}) {
+* key: The data that needs to be added to the file.
+* error: The error if the file cannot be written. */
+func saveJSON(fileName string, key interface{}) error {
 	outFile, err :=  os.OpenFile(fileName, os.O_RDWR|os.O_APPEND|os.O_CREATE,0660)
-	checkError(err)
+	if err != nil {
+		return err
+	}
 	encoder := json.NewEncoder(outFile)
 	err = encoder.Encode(key)
-	checkError(err)
 	outFile.Close()
+	return err
+}
+
+/* This function is used to log a line of the DICT3 file that cannot be read. The line is skipped instead of stopping the server.
+* input: The request that reads the file, the line number and the error. */
+func badline(input *JsonMessage, line int, err error) {
+	logger.Warn("skipping a line of the DICT3 file that cannot be read","node",serverconfig.Port,"request",input.RequestID,"file",serverconfig.PersistentStorageContainer.File,"line",line,"error",err)
+}
+
+/* This function is used to log an error of the DICT3 file and to return it to the client.
+* input: The request and the error.
+* error: The error that is returned to the client. */
+func storageerror(input *JsonMessage, err error) error {
+	logger.Error("the DICT3 file could not be accessed","node",serverconfig.Port,"request",input.RequestID,"file",serverconfig.PersistentStorageContainer.File,"error",err)
+	return errors.New("Storage error - "+err.Error())
+}
+
+/* This function is used to set up the log of the server from the log config.
+* error: The error if the level, the output or the format is invalid. */
+func initlogging() error {
+	var level slog.Level
+	if len(serverconfig.Log.Level) != 0 {
+		if err := level.UnmarshalText([]byte(serverconfig.Log.Level)); err != nil {
+			return errors.New("Config error - Invalid log level "+strconv.Quote(serverconfig.Log.Level))
+		}
+	}
+	var out io.Writer = os.Stderr
+	switch serverconfig.Log.Output {
+	case "", "stderr":
+	case "stdout":
+		out = os.Stdout
+	default:
+		file, err := os.OpenFile(serverconfig.Log.Output,os.O_WRONLY|os.O_APPEND|os.O_CREATE,0660)
+		if err != nil {
+			return errors.New("Config error - The log file could not be opened: "+err.Error())
+		}
+		out = file
+	}
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(serverconfig.Log.Format) {
+	case "", "text":
+		logger = slog.New(slog.NewTextHandler(out,options))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(out,options))
+	default:
+		return errors.New("Config error - Invalid log format "+strconv.Quote(serverconfig.Log.Format)+", use text or json")
+	}
+	return nil
 }
 
-/* This function is used to check for any errors returned by the function.
+/* This function is used to check for any errors returned by the function. It is only used where the server cannot continue,
+* while starting up, and logs the error and exits.
 * error: It refers to the error value that needs to be checked. */
 func checkError(err error) {
 	if err != nil {
-		fmt.Println("Fatal error ", err.Error())
+		logger.Error("fatal error","error",err)
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,1214 @@
+package main
+
+import (
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"fmt"
+	"log"
+	"os"
+	"io"
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"net"
+	"time"
+	"crypto/hmac"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/drakesh/CHORD-with-JSON-RPC/tabfile"
+)
+
+/* The authentication of a request. The signature is the hex encoded HMAC-SHA256, keyed with the secret of the user, of the
+ * function name, the user, the timestamp, the nonce and the JSON of the input, each on its own line.
+ * Timestamp: The time the request was signed in seconds since 1970.
+ * Nonce: A random value that is never used twice, so that a request cannot be replayed.*/
+type AuthToken struct{
+	User string `json:"user"`
+	Timestamp int64 `json:"timestamp"`
+	Nonce string `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+/* The part of the input of a request that is signed.*/
+type signedbody struct{
+	Params []interface{} `json:"params"`
+	TTL *int64 `json:"ttl,omitempty"`
+	Override bool `json:"override,omitempty"`
+	ExpectedVersion *int64 `json:"expectedVersion,omitempty"`
+}
+
+/* The credentials the client signs its requests with, the user and the secret have to match a user in the server config file.*/
+type CredentialsType struct{
+	User string `json:"user"`
+	Secret string `json:"secret"`
+}
+
+/* The TLS configuration of the connection to the server, TLS is used when a CA is given.
+ * CA: The certificate authority the certificate of the server is verified with.
+ * Cert, Key: The certificate and the private key of the client, needed when the server requires client certificates.
+ * ServerName: The name the certificate of the server is issued to, by default the IP address of the server.*/
+type TLSType struct{
+	CA string `json:"ca"`
+	Cert string `json:"cert"`
+	Key string `json:"key"`
+	ServerName string `json:"servername"`
+}
+
+/* The codec of the connection to the server. It wraps the JSON-RPC codec and signs every request with the credentials.*/
+type signingcodec struct{
+	rpc.ClientCodec
+	credentials CredentialsType
+}
+
+/* The JSON message structure of the input passed to the program.
+ * Method: Remote function to be called.
+ * Params: The input json object that is passed to the function.
+ * TTL: The optional time to live of the triplet in seconds for the insert functions. Zero keeps the triplet forever.
+ * Override: Allows an administrator to update, delete or chmod triplets that are read only or append only.
+ * Auth: The authentication of the request, it is added by the client when the config file has credentials.
+ * ExpectedVersion: The version the triplet must have for insertOrUpdate to succeed, zero if the triplet must not exist.
+ * Id: The unique id refers to the transaction between the client and the server.*/
+type JsonMessage struct{
+	Method string 	`json:"method"`
+	Params []interface{} `json:"params"`
+	Portno int `json:"port"`
+	TTL *int64 `json:"ttl,omitempty"`
+	Override bool `json:"override,omitempty"`
+	ExpectedVersion *int64 `json:"expectedVersion,omitempty"`
+	Auth *AuthToken `json:"auth,omitempty"`
+	Traceparent string `json:"traceparent,omitempty"`
+}
+
+/* The json result structure that will be displayed to the user after the completion
+ * of the insert and insertOrUpdate functions.
+ * Result: Indicates the successful or unsuccessful completion of the function.
+ * Version: The version of the inserted or updated triplet.
+ * Id: The unique id refers to the transaction between the client and server.
+ * Error: The error that is returned by the remote function call. */
+type JsonResultInsert struct{
+	Result bool `json:"result"`
+	Version int64 `json:"version,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The json result structure that will be displayed to the user after the completion
+ * of the compareAndSwap function.
+ * Result: Indicates the successful or unsuccessful completion of the function.
+ * Version: The new version of the triplet.
+ * Error: The error that is returned by the remote function call. */
+type JsonResultCAS struct{
+	Result bool `json:"result"`
+	Version int64 `json:"version,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the lookup function.
+ * Result: Indicates the return of the params identified by the key and the relationship.
+ * Versions: The version of every returned triplet, in the same order as the result.
+ * Id: The unique id refers to the transaction between the client and server.
+ * Error: The error that is returned by the remote function call. */
+type JsonResultLookUp struct{
+	Result []interface{} `json:"result"`
+	Versions []int64 `json:"versions,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The json result structure that will be displayed to the user after the completion
+ * of the listKey function.
+ * Result: Identifies the list of unique keys returned by the function.
+ * Id: The unique id refers to the transaction between the client and server.
+ * Error: The error that is returned by the remote function call. */
+type JsonListKeys struct{
+	Result []string `json:"result"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the listID function.
+ * Result: Identifies the list of unique key and relationship combination returned by the listID function.
+ * Id: The unique id refers to the transaction between the client and server.
+ * Error: The error that is returned by the remote function call. */
+type JsonListIDs struct{
+	Result [][]string `json:"result"`
+	Error string `json:"error"`
+}
+
+/* The metadata of a triplet that is returned by the stat function. The size is given in bytes
+ * and the timestamps in RFC3339. Owner and OwnerID refer to the node that stores the triplet. */
+type EntryStat struct{
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Size int64 `json:"size"`
+	Created string `json:"created"`
+	Modified string `json:"modified,omitempty"`
+	Accessed string `json:"accessed"`
+	Permission string `json:"permission"`
+	TTL int64 `json:"ttl"`
+	Owner int `json:"owner"`
+	OwnerID int `json:"ownerId"`
+	Version int64 `json:"version"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the stat function.
+ * Result: The metadata of the triplet.
+ * Error: The error that is returned by the remote function call. */
+type JsonStat struct{
+	Result *EntryStat `json:"result"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the transaction function.
+ * Result: Indicates whether the transaction has been committed.
+ * ID: The id of the transaction.
+ * Versions: The new version of the triplet of every operation, zero for a delete.
+ * Error: The error that is returned by the remote function call. */
+type JsonTransaction struct{
+	Result bool `json:"result"`
+	ID string `json:"id,omitempty"`
+	Versions []int64 `json:"versions,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the watch and unwatch functions.
+ * Result: The id of the new watch, or true when the watch has been removed.
+ * Error: The error that is returned by the remote function call. */
+type JsonWatch struct{
+	Result interface{} `json:"result"`
+	Error string `json:"error"`
+}
+
+/* A change of a watched triplet that is returned by the poll function. The op is "insert", "update",
+ * "delete", "expire" or "chmod", or "move" and "merge" when the triplet is handed to another node, and
+ * the value and version are the new ones, or the last ones for a delete or an expiry. */
+type ChangeEvent struct{
+	Op string `json:"op"`
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Value string `json:"value"`
+	Version int64 `json:"version"`
+	Owner int `json:"owner"`
+	Time string `json:"time"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the poll function.
+ * Result: The changes of the watched triplets since the last poll.
+ * Dropped: The number of changes that were dropped because the watch was not polled often enough.
+ * Error: The error that is returned by the remote function call. */
+type JsonPoll struct{
+	Result []ChangeEvent `json:"result"`
+	Dropped int `json:"dropped,omitempty"`
+	Error string `json:"error"`
+}
+
+/* A single entry of the change log of a node that is returned by the changes function. The old
+ * value is missing for an insert and the new value for a delete, an expiry or a triplet the node
+ * has handed to another node. */
+type ChangeRecord struct{
+	Seq int64 `json:"seq"`
+	Op string `json:"op"`
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	OldValue *string `json:"oldValue,omitempty"`
+	NewValue *string `json:"newValue,omitempty"`
+	OldVersion int64 `json:"oldVersion,omitempty"`
+	NewVersion int64 `json:"newVersion,omitempty"`
+	Time string `json:"time"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the changes function.
+ * Result: The changes of the node from the requested sequence number on.
+ * Node: The port no of the node.
+ * First, Last: The sequence numbers of the oldest and the newest change the node still keeps.
+ * Truncated: Indicates that some of the requested changes have been dropped by the retention.
+ * Error: The error that is returned by the remote function call. */
+type JsonChanges struct{
+	Result []ChangeRecord `json:"result"`
+	Node int `json:"node"`
+	First int64 `json:"first"`
+	Last int64 `json:"last"`
+	Truncated bool `json:"truncated,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the topology function.
+ * Result: The topology of the whole Chord ring.
+ * Dot: The same topology rendered in the Graphviz DOT language when it is requested.
+ * Error: The error that is returned by the remote function call. */
+type JsonTopology struct{
+	Result interface{} `json:"result"`
+	Dot string `json:"dot,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the verify function.
+ * Result: The report of the consistency check of the Chord ring.
+ * Error: The error that is returned by the remote function call. */
+type JsonVerify struct{
+	Result interface{} `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to transfer a single triplet together with its metadata.*/
+type DICT3record struct{
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Value string `json:"value"`
+	Size string `json:"size"`
+	Created string `json:"created"`
+	Modified string `json:"modified"`
+	Accessed string `json:"accessed"`
+	Permission string `json:"permission"`
+	TTL *int64 `json:"ttl,omitempty"`
+}
+
+/*This structure is used to write a triplet to a JSON-lines file. A value holding a JSON object is written as the
+*object itself, so that the file can also be read by the single node JSON-RPC server.*/
+type jsonlrow struct{
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Value json.RawMessage `json:"value"`
+	Size string `json:"size,omitempty"`
+	Created string `json:"created,omitempty"`
+	Modified string `json:"modified,omitempty"`
+	Accessed string `json:"accessed,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	TTL *int64 `json:"ttl,omitempty"`
+}
+
+/*This structure is used to describe a line of an imported file that could not be imported.*/
+type RejectedRow struct{
+	Line int `json:"line"`
+	Reason string `json:"reason"`
+}
+
+/* The JSON result structure returned by the export function for a single page of triplets.
+ * Result: The page of triplets sorted by key and relationship.
+ * Total: The total number of triplets stored on the ring.
+ * More: Whether there are triplets after the page.
+ * Error: The error that is returned by the remote function call. */
+type JsonExport struct{
+	Result []DICT3record `json:"result"`
+	Total int `json:"total"`
+	More bool `json:"more"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure returned by the import function for a single batch of triplets.
+ * Result: The number of triplets stored on the ring.
+ * Rejected: The records of the batch that could not be imported, identified by their index in the batch.
+ * Error: The error that is returned by the remote function call. */
+type JsonImport struct{
+	Result int `json:"result"`
+	Rejected []RejectedRow `json:"rejected"`
+	Error string `json:"error"`
+}
+
+/* The json result structure that will be displayed to the user after an export or an import.
+ * Result: The number of triplets written to or read from the file.
+ * Rejected: The lines of the file that could not be imported.
+ * Error: The error that is returned by the function. */
+type JsonTransfer struct{
+	Result int `json:"result"`
+	Rejected []RejectedRow `json:"rejected,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the snapshot and restore functions.
+ * Result: The directory of the archive.
+ * Nodes: The number of partitions in the archive.
+ * Entries: The number of triplets written to or restored from the archive.
+ * Error: The error that is returned by the remote function call. */
+type JsonArchive struct{
+	Result string `json:"result"`
+	Nodes int `json:"nodes"`
+	Entries int `json:"entries"`
+	Error string `json:"error"`
+}
+
+/*The columns of the CSV files written and read by the export and import functions. Files without the ttl column can also be imported.*/
+var csvheader = []string{"key","relationship","value","size","created","modified","accessed","permission","ttl"}
+
+/*This structure is used to display a storage quota and its usage.
+ * Prefix: The key prefix the quota applies to, empty for every key.
+ * Client: The client the quota applies to, empty for every client.
+ * Entries, MaxEntries: The triplets stored under the quota and the most it allows.
+ * Bytes, MaxBytes: The bytes stored under the quota and the most it allows. */
+type QuotaUsage struct{
+	Prefix string `json:"prefix"`
+	Client string `json:"client"`
+	Entries int `json:"entries"`
+	MaxEntries int `json:"maxEntries"`
+	Bytes int64 `json:"bytes"`
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+/*This structure is used to display a rate limit of the client.
+ * Method: The function the limit applies to, empty for the limit of all the calls.
+ * Rate, Burst: The calls per second and the calls that can be made at once.
+ * Tokens: The calls the client can make at once now. */
+type RateUsage struct{
+	Method string `json:"method"`
+	Rate float64 `json:"rate"`
+	Burst int `json:"burst"`
+	Tokens float64 `json:"tokens"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the quota function.
+ * Client: The client the quotas and rate limits are reported for.
+ * Result: The storage quotas that apply to the client with their usage.
+ * Rates: The rate limits of the client.
+ * Error: The error that is returned by the remote function call. */
+type JsonQuota struct{
+	Client string `json:"client"`
+	Result []QuotaUsage `json:"result"`
+	Rates []RateUsage `json:"rates"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the describe function.
+ * Result: The functions that can be called at the server.
+ * Error: The error that is returned by the remote function call. */
+type JsonDescribe struct{
+	Result []string `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to display a node in the output of the ping function.
+ * Port, ID: The port no and the ring position of the node.
+ * Uptime: The seconds since the node was started. */
+type NodePing struct{
+	Port int `json:"port"`
+	ID int `json:"id"`
+	Uptime float64 `json:"uptime"`
+}
+
+/*This structure is used to display the successor or the predecessor of a node.
+ * Port, ID: The port no and the ring position of the node.
+ * Alive: Whether the node is in the ring and accepts connections.
+ * Error: The reason the node is not alive. */
+type PeerHealth struct{
+	Port int `json:"port"`
+	ID int `json:"id"`
+	Alive bool `json:"alive"`
+	Error string `json:"error,omitempty"`
+}
+
+/*This structure is used to display the health of a node.
+ * Status: "ok", or the reason the node is not healthy or not ready.
+ * Successor, Predecessor: The health of the successor and of the predecessor of the node.
+ * Keys: The number of triplets the node stores that have not expired.
+ * LastStabilized: The last time the links of the node were set, empty if they never were.
+ * HandoffComplete: Whether the keys the node owns have been handed to it when it joined the ring.
+ * Healthy: Whether the node is in the ring and accepts connections.
+ * Ready: Whether the node is healthy, has stabilized, has its keys and its successor and predecessor are alive. */
+type NodeHealth struct{
+	NodePing
+	Status string `json:"status"`
+	Successor PeerHealth `json:"successor"`
+	Predecessor PeerHealth `json:"predecessor"`
+	Keys int `json:"keys"`
+	LastStabilized string `json:"lastStabilized"`
+	HandoffComplete bool `json:"handoffComplete"`
+	Healthy bool `json:"healthy"`
+	Ready bool `json:"ready"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the ping function.
+ * Result: The node that answered.
+ * Error: The error that is returned by the remote function call. */
+type JsonPing struct{
+	Result NodePing `json:"result"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the health and ready functions.
+ * Result: The health of the node.
+ * Error: The error that is returned by the remote function call. */
+type JsonHealth struct{
+	Result NodeHealth `json:"result"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the addNode function.
+ * Result: The port nos of the new nodes.
+ * Error: The error that is returned by the remote function call. */
+type JsonAddNode struct{
+	Result []int `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used when there is no need to display any output JSON message to the user. */
+type NoOutput struct {
+	Error string
+}
+
+/*The refers to the input JSON message structure that represents the configuration details of the client.
+ * Server ID: Refers to the remote server ID.
+ * Protocol: Refers to the protocol used to contact the remote server and is mostly TCP.
+ * IPAddress: Refers to the IP address of the remote server.
+ * Port: Refers to the port number being used to start the communication process.
+ * Methods: Contains the list of all the functions that can be called at the remote server.
+ * Credentials: The user and secret the requests are signed with when the server requires authentication.
+ * TLS: The TLS configuration of the connection to the server.
+ * Trace: Starts a trace for every request that has no traceparent and prints the trace id to the standard error. */
+type config struct{
+	ServerId string `json:"serverID"`
+	Protocol string `json:"protocol"`
+	IpAddress string `json:"ipAddress"`
+	Port int `json:"port"`
+	Methods []string `json:"methods"`
+	Credentials CredentialsType `json:"credentials"`
+	TLS TLSType `json:"tls"`
+	Trace bool `json:"trace"`
+}
+
+/*This function is used to connect to the server, over TLS when the config file has a CA.
+*input: The client config and the address of the server.
+*output: The connection and the error if the connection or the TLS handshake fails.*/
+func dial(clientconfig config, service string) (net.Conn, error) {
+	if len(clientconfig.TLS.CA) == 0 {
+		return net.Dial(clientconfig.Protocol, service)
+	}
+	pem, err := os.ReadFile(clientconfig.TLS.CA)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificate found in " + clientconfig.TLS.CA)
+	}
+	tlsconfig := &tls.Config{RootCAs: pool, ServerName: clientconfig.TLS.ServerName, MinVersion: tls.VersionTLS12}
+	if len(tlsconfig.ServerName) == 0 {
+		tlsconfig.ServerName = clientconfig.IpAddress
+	}
+	if len(clientconfig.TLS.Cert) != 0 {
+		pair, err := tls.LoadX509KeyPair(clientconfig.TLS.Cert, clientconfig.TLS.Key)
+		if err != nil {
+			return nil, err
+		}
+		tlsconfig.Certificates = []tls.Certificate{pair}
+	}
+	return tls.Dial(clientconfig.Protocol, service, tlsconfig)
+}
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Println("Usage: ", os.Args[0], "Enter config Json file path")
+		log.Fatal(1)
+	}
+	inFile, err := os.Open(os.Args[1])
+	if err != nil {
+			log.Fatal("Opeining the Json Config file :", err)
+	}
+	scanner := bufio.NewScanner(inFile)
+
+	//Unmarshalling the input config fille.
+	var clientconfig config
+	for scanner.Scan() {
+		err := json.Unmarshal([]byte(scanner.Text()),&clientconfig)
+		if err != nil {
+			log.Fatal("Error Unsmrashalling the Json config file:", err)
+		}
+	}
+	service := clientconfig.IpAddress +":" + strconv.Itoa(clientconfig.Port)
+
+	//Refers to the establishment of the tcp connection between the client and the server.
+	conn, err := dial(clientconfig, service)
+	if err != nil {
+		log.Fatal("dialing:", err)
+	}
+	client := rpc.NewClientWithCodec(&signingcodec{jsonrpc.NewClientCodec(conn),clientconfig.Credentials})
+
+	if client == nil{
+		log.Fatal("dialing:", err)
+	}
+
+	//The methods of the config file are checked against the methods the server provides before any input is read.
+	JsonInput := JsonMessage{Method: "describe", Portno: clientconfig.Port}
+	described := new(JsonDescribe)
+	if err := client.Call("Dict3.Describe",JsonInput,described); err != nil {
+		log.Fatal("Describing the methods of the server:", err)
+	}
+	if len(clientconfig.Methods) == 0 {
+		clientconfig.Methods = described.Result
+	}
+	missing := []string{}
+	for _,method := range clientconfig.Methods {
+		if !hasmethod(described.Result,method) {
+			missing = append(missing,method)
+		}
+	}
+	if len(missing) > 0 {
+		log.Fatal("The server does not provide the methods of the config file: ", strings.Join(missing,", "))
+	}
+	scanner = bufio.NewScanner(os.Stdin)
+  for scanner.Scan() {
+	  text := scanner.Text()
+		JsonInput = JsonMessage{Portno: clientconfig.Port}
+
+		//Unmarshalling the input JSON message.
+		err = json.Unmarshal([]byte(text),&JsonInput)
+		if err != nil {
+			log.Fatal("Json Error:", err)
+		}
+
+		if clientconfig.Trace && len(JsonInput.Traceparent) == 0 {
+			JsonInput.Traceparent = newtraceparent()
+			fmt.Fprintln(os.Stderr,"trace",strings.Split(JsonInput.Traceparent,"-")[1])
+		}
+
+		if JsonInput.Method != "describe" && !hasmethod(clientconfig.Methods,JsonInput.Method) {
+			fmt.Println("The input method is not in the config file. Check the config file to see which method exists at the server")
+			continue
+		}
+
+		//Calling the appropriate function that is referred in the input JSON message.
+		//The result is displayed in the console depending on the function that is being called.
+		switch{
+		case JsonInput.Method == "lookup":
+			resultlookup := new(JsonResultLookUp)
+			lookupcall := client.Go("Dict3.LookUp",JsonInput,resultlookup,nil)
+			replycall := <-lookupcall.Done
+			if replycall.Error == nil {
+				resultlookup.Error = "null"
+			}else{
+				resultlookup.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultlookup)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+
+		case JsonInput.Method == "insert":
+			resultinsert := new(JsonResultInsert)
+			insertcall := client.Go("Dict3.Insert",JsonInput,resultinsert,nil)
+			replycall := <-insertcall.Done
+			if replycall.Error == nil {
+				resultinsert.Error = "null"
+			}else{
+				resultinsert.Result = false
+				resultinsert.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultinsert)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "insertOrUpdate":
+			resultinsert := new(JsonResultInsert)
+			insertorupdatecall := client.Go("Dict3.InsertOrUpdate",JsonInput,resultinsert,nil)
+			replycall := <-insertorupdatecall.Done
+			if replycall.Error == nil {
+				resultinsert.Error = "null"
+			}else{
+				resultinsert.Result = false
+				resultinsert.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultinsert)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "delete":
+			nooutput := new(NoOutput)
+			deletecall := client.Go("Dict3.Delete",JsonInput,nooutput,nil)
+			replycall := <-deletecall.Done
+			if replycall.Error != nil {
+				nooutput.Error = replycall.Error.Error()
+				JsonOutput, err := json.Marshal(nooutput)
+				if err != nil {
+					log.Fatal("Marshaling the result to display:", err)
+				}
+				fmt.Printf("%s\n",JsonOutput)
+			}
+		case JsonInput.Method == "compareAndSwap":
+			resultcas := new(JsonResultCAS)
+			cascall := client.Go("Dict3.CompareAndSwap",JsonInput,resultcas,nil)
+			replycall := <-cascall.Done
+			if replycall.Error == nil {
+				resultcas.Error = "null"
+			}else{
+				resultcas.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultcas)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "transaction":
+			resulttransaction := new(JsonTransaction)
+			transactioncall := client.Go("Dict3.Transaction",JsonInput,resulttransaction,nil)
+			replycall := <-transactioncall.Done
+			if replycall.Error == nil {
+				resulttransaction.Error = "null"
+			}else{
+				resulttransaction.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resulttransaction)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "watch" || JsonInput.Method == "unwatch":
+			resultwatch := new(JsonWatch)
+			servicemethod := "Dict3.Watch"
+			if JsonInput.Method == "unwatch" {
+				servicemethod = "Dict3.Unwatch"
+			}
+			watchcall := client.Go(servicemethod,JsonInput,resultwatch,nil)
+			replycall := <-watchcall.Done
+			if replycall.Error == nil {
+				resultwatch.Error = "null"
+			}else{
+				resultwatch.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultwatch)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "poll":
+			resultpoll := new(JsonPoll)
+			pollcall := client.Go("Dict3.Poll",JsonInput,resultpoll,nil)
+			replycall := <-pollcall.Done
+			if replycall.Error == nil {
+				resultpoll.Error = "null"
+			}else{
+				resultpoll.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultpoll)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "changes":
+			resultchanges := new(JsonChanges)
+			changescall := client.Go("Dict3.Changes",JsonInput,resultchanges,nil)
+			replycall := <-changescall.Done
+			if replycall.Error == nil {
+				resultchanges.Error = "null"
+			}else{
+				resultchanges.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultchanges)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "chmod":
+			nooutput := new(NoOutput)
+			chmodcall := client.Go("Dict3.Chmod",JsonInput,nooutput,nil)
+			replycall := <-chmodcall.Done
+			if replycall.Error != nil {
+				nooutput.Error = replycall.Error.Error()
+				JsonOutput, err := json.Marshal(nooutput)
+				if err != nil {
+					log.Fatal("Marshaling the result to display:", err)
+				}
+				fmt.Printf("%s\n",JsonOutput)
+			}
+		case JsonInput.Method == "stat":
+			resultstat := new(JsonStat)
+			statcall := client.Go("Dict3.Stat",JsonInput,resultstat,nil)
+			replycall := <-statcall.Done
+			if replycall.Error == nil {
+				resultstat.Error = "null"
+			}else{
+				resultstat.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultstat)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "listKeys":
+			resultlistkeys := new(JsonListKeys)
+			listkeyscall := client.Go("Dict3.ListKeys",JsonInput,resultlistkeys,nil)
+			replycall := <-listkeyscall.Done
+			if replycall.Error == nil {
+				resultlistkeys.Error = "null"
+			}else{
+				resultlistkeys.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultlistkeys)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "listIDs":
+			resultlistIDs := new(JsonListIDs)
+			listIDscall := client.Go("Dict3.ListIDs",JsonInput,resultlistIDs,nil)
+			replycall := <-listIDscall.Done
+			if replycall.Error == nil {
+				resultlistIDs.Error = "null"
+			}else{
+				resultlistIDs.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultlistIDs)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "purge":
+			nooutput := new(NoOutput)
+			purgeCall := client.Go("Dict3.Purge",JsonInput,nooutput,nil)
+			purgeresult := <-purgeCall.Done
+			if purgeresult.Error == nil {
+				fmt.Println("The CHORD ring was successfully purged of any stale entries.")
+			}else{
+				fmt.Println("There was an error purging the CHORD ring - ",purgeresult.Error)
+			}
+		case JsonInput.Method == "topology":
+			resulttopology := new(JsonTopology)
+			topologycall := client.Go("Dict3.Topology",JsonInput,resulttopology,nil)
+			replycall := <-topologycall.Done
+			if replycall.Error == nil && len(resulttopology.Dot) != 0 {
+				fmt.Print(resulttopology.Dot)
+				break
+			}
+			if replycall.Error == nil {
+				resulttopology.Error = "null"
+			}else{
+				resulttopology.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resulttopology)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "verify":
+			resultverify := new(JsonVerify)
+			verifycall := client.Go("Dict3.Verify",JsonInput,resultverify,nil)
+			replycall := <-verifycall.Done
+			if replycall.Error == nil {
+				resultverify.Error = "null"
+			}else{
+				resultverify.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultverify)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "export" || JsonInput.Method == "import":
+			resulttransfer := new(JsonTransfer)
+			format, filename, err := transferparams(JsonInput)
+			if err == nil && JsonInput.Method == "export" {
+				resulttransfer.Result, err = exportDICT3(client,JsonInput.Portno,format,filename)
+			} else if err == nil {
+				resulttransfer.Result, resulttransfer.Rejected, err = importDICT3(client,JsonInput.Portno,format,filename)
+			}
+			if err == nil {
+				resulttransfer.Error = "null"
+			}else{
+				resulttransfer.Error = err.Error()
+			}
+			JsonOutput, err := json.Marshal(resulttransfer)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "snapshot" || JsonInput.Method == "restore":
+			resultarchive := new(JsonArchive)
+			servicemethod := "Dict3.Snapshot"
+			if JsonInput.Method == "restore" {
+				servicemethod = "Dict3.Restore"
+			}
+			archivecall := client.Go(servicemethod,JsonInput,resultarchive,nil)
+			replycall := <-archivecall.Done
+			if replycall.Error == nil {
+				resultarchive.Error = "null"
+			}else{
+				resultarchive.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultarchive)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "addNode":
+			resultaddnode := new(JsonAddNode)
+			addnodecall := client.Go("Dict3.AddNode",JsonInput,resultaddnode,nil)
+			replycall := <-addnodecall.Done
+			if replycall.Error == nil {
+				resultaddnode.Error = "null"
+			}else{
+				resultaddnode.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultaddnode)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "quota":
+			resultquota := new(JsonQuota)
+			quotacall := client.Go("Dict3.Quota",JsonInput,resultquota,nil)
+			replycall := <-quotacall.Done
+			if replycall.Error == nil {
+				resultquota.Error = "null"
+			}else{
+				resultquota.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultquota)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "ping":
+			resultping := new(JsonPing)
+			pingcall := client.Go("Dict3.Ping",JsonInput,resultping,nil)
+			replycall := <-pingcall.Done
+			if replycall.Error == nil {
+				resultping.Error = "null"
+			}else{
+				resultping.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultping)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "health":
+			resulthealth := new(JsonHealth)
+			healthcall := client.Go("Dict3.Health",JsonInput,resulthealth,nil)
+			replycall := <-healthcall.Done
+			if replycall.Error == nil {
+				resulthealth.Error = "null"
+			}else{
+				resulthealth.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resulthealth)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "ready":
+			resultready := new(JsonHealth)
+			readycall := client.Go("Dict3.Ready",JsonInput,resultready,nil)
+			replycall := <-readycall.Done
+			if replycall.Error == nil {
+				resultready.Error = "null"
+			}else{
+				resultready.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultready)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "describe":
+			resultdescribe := new(JsonDescribe)
+			describecall := client.Go("Dict3.Describe",JsonInput,resultdescribe,nil)
+			replycall := <-describecall.Done
+			if replycall.Error == nil {
+				resultdescribe.Error = "null"
+			}else{
+				resultdescribe.Error = replycall.Error.Error()
+			}
+			JsonOutput, err := json.Marshal(resultdescribe)
+			if err != nil {
+				log.Fatal("Marshaling the result to display:", err)
+			}
+			fmt.Printf("%s\n",JsonOutput)
+		case JsonInput.Method == "shutdown":
+			nooutput := new(NoOutput)
+			shutdowncall := client.Go("Dict3.Shutdown",JsonInput,nooutput,nil)
+			_ = <-shutdowncall.Done
+		default:
+			fmt.Println("The input method does not exist. Check the config file to see which method exists at the server")
+		}
+	}
+}
+
+/*This function is used to check whether a method is in a list of methods, the names are not case sensitive.
+*input: The list of methods and the method.
+*output: True if the method is in the list.*/
+func hasmethod(methods []string, method string) bool {
+	for _,m := range methods {
+		if strings.EqualFold(m,method) {
+			return true
+		}
+	}
+	return false
+}
+
+/*This function writes a request to the server, signed with the credentials of the client when there are any.*/
+func (c *signingcodec) WriteRequest(r *rpc.Request, body interface{}) error {
+	if input, ok := body.(JsonMessage); ok && len(c.credentials.User) != 0 {
+		nonce := make([]byte,16)
+		if _,err := rand.Read(nonce); err != nil {
+			return err
+		}
+		input.Auth = &AuthToken{c.credentials.User,time.Now().Unix(),hex.EncodeToString(nonce),""}
+		input.Auth.Signature = signrequest(r.ServiceMethod,&input,input.Auth,c.credentials.Secret)
+		body = input
+	}
+	return c.ClientCodec.WriteRequest(r,body)
+}
+
+/*This function is used to start a trace, it returns a W3C traceparent with a random trace id and span id that is sampled.*/
+func newtraceparent() string {
+	id := make([]byte,24)
+	rand.Read(id)
+	return "00-"+hex.EncodeToString(id[:16])+"-"+hex.EncodeToString(id[16:])+"-01"
+}
+
+/*This function is used to compute the signature of a request.
+*input: The function that is called, the input of the request, its authentication and the secret of the user.
+*output: The hex encoded signature.*/
+func signrequest(method string, input *JsonMessage, token *AuthToken, secret string) string {
+	//The params are normalized through a generic JSON value so that the client and the server sign the same bytes.
+	var params []interface{}
+	raw, _ := json.Marshal(input.Params)
+	json.Unmarshal(raw,&params)
+	body, _ := json.Marshal(signedbody{params,input.TTL,input.Override,input.ExpectedVersion})
+	mac := hmac.New(sha256.New,[]byte(secret))
+	mac.Write([]byte(method+"\n"+token.User+"\n"+strconv.FormatInt(token.Timestamp,10)+"\n"+token.Nonce+"\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*This function is used to read the format and the file name of an export or an import.
+*input: The input JSON message. The parameters are the format ("jsonl", "csv" or "tab") and the name of the file.
+*output: The format, the file name and the error if any of them is missing or invalid.*/
+func transferparams(input JsonMessage) (string, string, error) {
+	if len(input.Params) != 2 {
+		return "","",errors.New("Params error - The format and the file name are required")
+	}
+	format, _ := input.Params[0].(string)
+	filename, _ := input.Params[1].(string)
+	format = strings.ToLower(format)
+	if format != "jsonl" && format != "csv" && format != "tab" {
+		return "","",errors.New("Format error - The format has to be jsonl, csv or tab")
+	}
+	if len(filename) == 0 {
+		return "","",errors.New("Params error - The file name cannot be empty")
+	}
+	return format,filename,nil
+}
+
+/*This function is used to stream all the triplets of the ring to a file, one page at a time.
+*input: The connection to the server, the port of the entry node, the format and the name of the file.
+*output: The number of triplets written and the error if any.*/
+func exportDICT3(client *rpc.Client, portno int, format, filename string) (int, error) {
+	outFile, err := os.Create(filename)
+	if err != nil {
+		return 0,err
+	}
+	defer outFile.Close()
+	w := bufio.NewWriter(outFile)
+	csvwriter := csv.NewWriter(w)
+	if format == "csv" {
+		csvwriter.Write(csvheader)
+	} else if format == "tab" {
+		w.WriteString(tabfile.Header+"\n")
+	}
+	written := 0
+	key, relationship := "", ""
+	for {
+		page := new(JsonExport)
+		if err := client.Call("Dict3.Export",JsonMessage{Method: "export", Params: []interface{}{key,relationship,500}, Portno: portno},page); err != nil {
+			return written,err
+		}
+		for _,r := range page.Result {
+			switch format {
+			case "jsonl":
+				line, err := json.Marshal(jsonlrow{r.Key,r.Relationship,jsonvalue(r.Value),r.Size,r.Created,r.Modified,r.Accessed,r.Permission,r.TTL})
+				if err != nil {
+					return written,err
+				}
+				w.Write(append(line,'\n'))
+			case "csv":
+				ttl := ""
+				if r.TTL != nil {
+					ttl = strconv.FormatInt(*r.TTL,10)
+				}
+				csvwriter.Write([]string{r.Key,r.Relationship,r.Value,r.Size,r.Created,r.Modified,r.Accessed,r.Permission,ttl})
+			case "tab":
+				fields := []string{r.Key,r.Relationship,r.Value,r.Size,r.Created,r.Modified,r.Accessed,r.Permission}
+				for i := range fields {
+					fields[i] = tabfile.Escape(fields[i])
+				}
+				w.WriteString(strings.Join(fields,"\t")+"\n")
+			}
+			written++
+			key, relationship = r.Key,r.Relationship
+		}
+		if len(page.Result) == 0 || !page.More {
+			break
+		}
+	}
+	csvwriter.Flush()
+	if err := csvwriter.Error(); err != nil {
+		return written,err
+	}
+	return written,w.Flush()
+}
+
+/*This function is used to read all the triplets of a file and to store them on the ring in batches.
+*input: The connection to the server, the port of the entry node, the format and the name of the file.
+*output: The number of triplets imported, the lines that were rejected and the error if any.*/
+func importDICT3(client *rpc.Client, portno int, format, filename string) (int, []RejectedRow, error) {
+	inFile, err := os.Open(filename)
+	if err != nil {
+		return 0,nil,err
+	}
+	defer inFile.Close()
+	imported := 0
+	rejected := []RejectedRow{}
+	batch := []DICT3record{}
+	lines := []int{}
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		result := new(JsonImport)
+		if err := client.Call("Dict3.Import",JsonMessage{Method: "import", Params: []interface{}{batch}, Portno: portno},result); err != nil {
+			return err
+		}
+		imported += result.Result
+		for _,row := range result.Rejected {
+			rejected = append(rejected,RejectedRow{lines[row.Line],row.Reason})
+		}
+		batch = batch[:0]
+		lines = lines[:0]
+		return nil
+	}
+	add := func(line int, r DICT3record) error {
+		batch = append(batch,r)
+		lines = append(lines,line)
+		if len(batch) == 500 {
+			return flush()
+		}
+		return nil
+	}
+
+	if format == "csv" {
+		reader := csv.NewReader(bufio.NewReader(inFile))
+		reader.FieldsPerRecord = -1
+		for line := 1; ; line++ {
+			fields, err := reader.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return imported,rejected,err
+			}
+			if line == 1 && strings.Join(fields,",") == strings.Join(csvheader[:len(fields)],",") {
+				continue
+			}
+			if len(fields) != len(csvheader) && len(fields) != len(csvheader)-1 {
+				rejected = append(rejected,RejectedRow{line,"expected "+strconv.Itoa(len(csvheader))+" columns, found "+strconv.Itoa(len(fields))})
+				continue
+			}
+			var ttl *int64
+			if len(fields) == len(csvheader) && len(fields[8]) != 0 {
+				seconds, err := strconv.ParseInt(fields[8],10,64)
+				if err != nil {
+					rejected = append(rejected,RejectedRow{line,"invalid ttl "+strconv.Quote(fields[8])})
+					continue
+				}
+				ttl = &seconds
+			}
+			if err := add(line,DICT3record{fields[0],fields[1],fields[2],fields[3],fields[4],fields[5],fields[6],fields[7],ttl}); err != nil {
+				return imported,rejected,err
+			}
+		}
+		err := flush()
+		return imported,rejected,err
+	}
+
+	scanner := bufio.NewScanner(inFile)
+	scanner.Buffer(make([]byte,64*1024),64*1024*1024)
+	escaped := false
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSuffix(scanner.Text(),"\r")
+		if len(strings.TrimSpace(text)) == 0 {
+			continue
+		}
+		if line == 1 && format == "tab" && text == tabfile.Header {
+			escaped = true
+			continue
+		}
+		var r DICT3record
+		if format == "jsonl" {
+			var row jsonlrow
+			if err := json.Unmarshal([]byte(text),&row); err != nil {
+				rejected = append(rejected,RejectedRow{line,"invalid JSON - "+err.Error()})
+				continue
+			}
+			r = DICT3record{row.Key,row.Relationship,stringvalue(row.Value),row.Size,row.Created,row.Modified,row.Accessed,row.Permission,row.TTL}
+		} else {
+			fields := strings.Split(text,"\t")
+			if len(fields) < 8 || (escaped && len(fields) != 8) {
+				rejected = append(rejected,RejectedRow{line,"expected 8 tab separated fields, found "+strconv.Itoa(len(fields))})
+				continue
+			}
+			if escaped {
+				for i := range fields {
+					fields[i] = tabfile.Unescape(fields[i])
+				}
+			}
+			//Files written before the fields were escaped may contain raw tabs in the value, so everything between the relationship and the metadata is the value.
+			n := len(fields)
+			r = DICT3record{fields[0],fields[1],strings.Join(fields[2:n-5],"\t"),fields[n-5],fields[n-4],fields[n-3],fields[n-2],fields[n-1],nil}
+		}
+		if err := add(line,r); err != nil {
+			return imported,rejected,err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return imported,rejected,err
+	}
+	err = flush()
+	return imported,rejected,err
+}
+
+/*This function is used to convert a stored value to the value written to a JSON-lines file. A value that holds
+*a compact JSON object is written as the object, any other value is written as a string.
+*input: The stored value.
+*output: The JSON encoding of the value.*/
+func jsonvalue(value string) json.RawMessage {
+	var compact bytes.Buffer
+	if strings.HasPrefix(value,"{") && json.Compact(&compact,[]byte(value)) == nil && compact.String() == value {
+		return json.RawMessage(value)
+	}
+	quoted, _ := json.Marshal(value)
+	return json.RawMessage(quoted)
+}
+
+/*This function is used to convert a value read from a JSON-lines file to the stored value.
+*input: The JSON encoding of the value.
+*output: The string held by the value or the compact JSON text of any other value.*/
+func stringvalue(raw json.RawMessage) string {
+	var value string
+	if json.Unmarshal(raw,&value) == nil {
+		return value
+	}
+	var compact bytes.Buffer
+	if json.Compact(&compact,raw) == nil {
+		return compact.String()
+	}
+	return string(raw)
+}
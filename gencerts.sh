@@ -0,0 +1,28 @@
+#!/bin/sh
+# Generates test certificates with openssl: a CA for the clients and the client port of the server, and a separate CA for
+# the Chord nodes, so that a client certificate cannot be used to connect to the nodes.
+# Usage: ./gencerts.sh [directory]   (default "certs")
+set -e
+dir=${1:-certs}
+mkdir -p "$dir"
+cd "$dir"
+
+# ca <name> <common name>
+ca() {
+	openssl req -x509 -newkey rsa:2048 -nodes -days 365 -subj "/CN=$2" -keyout "$1.key" -out "$1.crt"
+}
+
+# issue <name> <common name> <ca> <extended key usage>
+issue() {
+	openssl req -newkey rsa:2048 -nodes -subj "/CN=$2" -keyout "$1.key" -out "$1.csr"
+	printf "subjectAltName=DNS:localhost,IP:127.0.0.1\nextendedKeyUsage=%s\n" "$4" > "$1.ext"
+	openssl x509 -req -in "$1.csr" -CA "$3.crt" -CAkey "$3.key" -CAcreateserial -days 365 -extfile "$1.ext" -out "$1.crt"
+	rm -f "$1.csr" "$1.ext"
+}
+
+ca ca "DICT3 client CA"
+ca node-ca "DICT3 node CA"
+issue server "DICT3 server" ca serverAuth
+issue client "DICT3 client" ca clientAuth
+issue node "DICT3 node" node-ca serverAuth,clientAuth
+echo "Certificates written to $dir"
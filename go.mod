@@ -0,0 +1,3 @@
+module github.com/drakesh/CHORD-with-JSON-RPC
+
+go 1.21
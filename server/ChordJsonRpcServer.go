@@ -0,0 +1,6859 @@
+package main
+
+import (
+	"fmt"
+	"bufio"
+	"net/rpc"
+	"strings"
+	"net/rpc/jsonrpc"
+	"os"
+	"net"
+	"errors"
+	"time"
+	"encoding/json"
+	"strconv"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"path/filepath"
+	"io"
+	"crypto/sha256"
+	"crypto/rand"
+	"encoding/hex"
+	"crypto/hmac"
+	"crypto/tls"
+	"crypto/x509"
+	"reflect"
+	"net/http"
+	"log/slog"
+	"sync/atomic"
+	"context"
+	mathrand "math/rand"
+	"bytes"
+	"unicode"
+	"github.com/drakesh/CHORD-with-JSON-RPC/tabfile"
+)
+/*Refers to the integer structure that points to the function that is being called. It is used for
+*registering the rpc service.*/
+type Dict3 int
+
+type FileType struct{
+	File string `json:"file"`
+}
+
+/*The refers to the configuration of the per node storage engine.
+ * Dir: The directory that holds the write-ahead log and the snapshot of every node.
+ * Fsync: The fsync policy of the write-ahead log. "always" syncs every write, "interval" syncs once a second and "never" leaves it to the operating system.
+ * SnapshotInterval: The number of seconds between two snapshots of a node. The write-ahead log is truncated after every snapshot.
+ * ArchiveDir: The directory that holds the archives written by the snapshot function.*/
+type StorageType struct{
+	Dir string `json:"dir"`
+	Fsync string `json:"fsync"`
+	SnapshotInterval int `json:"snapshotinterval"`
+	ArchiveDir string `json:"archivedir"`
+}
+
+/*The refers to the configuration of the authentication of the requests. The authentication is enabled when there are users.
+ * Users: The users that can call the functions, each with a secret and a role.
+ * MaxSkew: The number of seconds the timestamp of a request may differ from the clock of the server (default 300).*/
+type AuthType struct{
+	Users []AuthUser `json:"users"`
+	MaxSkew int `json:"maxskew"`
+	Disabled bool `json:"disabled"`
+}
+
+/*The refers to a user that can call the functions.
+ * Role: "read" can only read the ring, "write" can also change triplets and "admin" can also call shutdown, purge, addNode,
+ * snapshot, restore and verify and use the override flag.*/
+type AuthUser struct{
+	User string `json:"user"`
+	Secret string `json:"secret"`
+	Role string `json:"role"`
+}
+
+/*The refers to the configuration of the audit log.
+ * File: The audit log, by default audit.log in the storage directory.
+ * MaxSize: The size in bytes at which the audit log is rotated (default 10 MB).
+ * Backups: The number of rotated audit logs that are kept (default 5).*/
+type AuditType struct{
+	File string `json:"file"`
+	MaxSize int64 `json:"maxsize"`
+	Backups int `json:"backups"`
+}
+
+/*The refers to the configuration of the change log of every node.
+ * Retention: The number of changes every node keeps (default 10000).
+ * MaxAge: The number of seconds a change is kept, zero keeps changes until the retention is reached.*/
+type CDCType struct{
+	Retention int `json:"retention"`
+	MaxAge int `json:"maxage"`
+}
+
+/*The refers to the configuration of the rate limits and the storage quotas of the clients. A client is identified by its user
+*when the requests are authenticated and by its IP address otherwise.
+ * Client: The rate of all the calls of a client.
+ * Methods: The rate of the calls of a client to a function, the names are those of the methods of the config file.
+ * Quotas: The storage quotas.*/
+type LimitsType struct{
+	Client RateType `json:"client"`
+	Methods map[string]RateType `json:"methods"`
+	Quotas []QuotaType `json:"quotas"`
+}
+
+/*The refers to a token bucket rate limit. A zero rate is not limited.
+ * Rate: The number of calls per second.
+ * Burst: The number of calls that can be made at once (default the rate rounded up).*/
+type RateType struct{
+	Rate float64 `json:"rate"`
+	Burst int `json:"burst"`
+}
+
+/*The refers to a storage quota on the triplets whose key starts with the prefix and that were created by the client. An empty
+*prefix matches every key, an empty client matches every client and the client "*" gives each client its own quota.
+ * Entries, Bytes: The maximum number of triplets and bytes of values, zero is not limited.*/
+type QuotaType struct{
+	Prefix string `json:"prefix"`
+	Client string `json:"client"`
+	Entries int `json:"entries"`
+	Bytes int64 `json:"bytes"`
+}
+
+/*The refers to the configuration of the tracing of the requests. Tracing is enabled when the spans are exported to a file or
+*to a collector.
+ * File: The file every trace is appended to as a JSON line in the OpenTelemetry (OTLP) JSON format.
+ * URL: The URL of a collector every trace is posted to in the same format, e.g. http://127.0.0.1:4318/v1/traces.
+ * Sample: The fraction of the requests without a trace context that are traced (default 1).*/
+type TracingType struct{
+	File string `json:"file"`
+	URL string `json:"url"`
+	Sample float64 `json:"sample"`
+}
+
+/*The refers to the configuration of the transport the nodes call each other over.
+ * Kind: "memory", the nodes call each other over connections within the process, or "tcp", JSON-RPC over TCP with mutual TLS
+ * on the ports of the nodetls config. The default is tcp when the nodetls config is set and memory otherwise.
+ * Latency: The milliseconds every call between two nodes is delayed by, for testing.
+ * Loss: The probability that a call between two nodes is lost, for testing.
+ * Partitions: Groups of port nos, the nodes of a group can only call the nodes of the same group, for testing.*/
+type TransportType struct{
+	Kind string `json:"kind"`
+	Latency int `json:"latency"`
+	Loss float64 `json:"loss"`
+	Partitions [][]int `json:"partitions"`
+}
+
+/*The refers to the configuration of the detection of the partitions of the ring. Every node probes the nodes it has seen, and
+*the nodes that cannot reach each other form separate rings until they can again, when the rings are merged.
+ * ProbeInterval: The seconds between the probes (default 5), -1 turns the probes off.
+ * Forget: The seconds after which a node that does not answer the probes is forgotten (default 600).*/
+type PartitionType struct{
+	ProbeInterval int `json:"probeinterval"`
+	Forget int `json:"forget"`
+}
+
+/*The refers to the configuration of the log of the server.
+ * Level: The lowest level that is logged, "debug", "info" (default), "warn" or "error".
+ * Output: "stderr" (default), "stdout" or the file the log is appended to.
+ * Format: "text" (default) or "json".*/
+type LogType struct{
+	Level string `json:"level"`
+	Output string `json:"output"`
+	Format string `json:"format"`
+}
+
+/*The refers to the configuration of the metrics of the nodes. Every node serves its metrics in the Prometheus text format
+*at the path /metrics over HTTP on its port no plus the offset.
+ * Disabled: Turns the metrics off.
+ * PortOffset: The offset of the HTTP port of the metrics from the port of the node (default 2000).*/
+type MetricsType struct{
+	Disabled bool `json:"disabled"`
+	PortOffset int `json:"portoffset"`
+}
+
+/*The refers to the TLS configuration of the port the clients connect to. TLS is used when a certificate and a key are given.
+ * Cert, Key: The certificate and the private key of the server.
+ * CA: The certificate authority the client certificates are verified with.
+ * ClientAuth: Requires every client to present a certificate signed by the CA.*/
+type TLSType struct{
+	Cert string `json:"cert"`
+	Key string `json:"key"`
+	CA string `json:"ca"`
+	ClientAuth bool `json:"clientauth"`
+}
+
+/*The refers to the mutual TLS configuration of the traffic between the Chord nodes. The nodes only accept connections from
+ * each other when a certificate, a key and a CA are given, and every node has to present a certificate signed by the CA.
+ * PortOffset: Every node listens for the other nodes on its port no plus the offset (default 1000).*/
+type NodeTLSType struct{
+	Cert string `json:"cert"`
+	Key string `json:"key"`
+	CA string `json:"ca"`
+	PortOffset int `json:"portoffset"`
+}
+
+/*The refers to the input JSON message structure that represents the configuration details of the server.
+ * Client ID: Refers to the client ID.
+ * Protocol: Refers to the protocol used to contact the remote server and is mostly TCP.
+ * IPAddress: Refers to the IP address of the client.
+ * Port: Refers to the port number being used to start the communication process.
+ * PersistentStorageContainer: The location of the DICT3 file.
+ * Storage: The configuration of the durable storage of every node.
+ * CDC: The configuration of the change log of every node.
+ * Audit: The configuration of the audit log.
+ * Auth: The users that can call the functions and their roles.
+ * Limits: The rate limits and storage quotas of the clients.
+ * TLS: The TLS configuration of the port the clients connect to.
+ * NodeTLS: The mutual TLS configuration of the traffic between the nodes.
+ * Metrics: The configuration of the metrics of the nodes.
+ * Log: The configuration of the log of the server.
+ * Tracing: The configuration of the tracing of the requests.
+ * Transport: The configuration of the transport between the nodes.
+ * Partition: The configuration of the detection of the partitions of the ring.
+ * DeleteTimeOut: The default time to live of a triplet in seconds, measured from its last access. Zero or less keeps triplets forever.
+ * SweepInterval: The number of seconds between two sweeps of the expired triplets of a node.
+ * Methods: Contains the list of all the functions that can be called at the remote server, not case sensitive. The other
+ * functions are rejected as if they did not exist, and all of them can be called when the list is empty. */
+type config struct{
+	ServerID string `json:"serverID"`
+	Protocol string `json:"protocol"`
+	IpAddress string `json:"ipAddress"`
+	Port int `json:"port"`
+	PersistentStorageContainer FileType `json:"persistentStorageContainer"`
+	Storage StorageType `json:"storage"`
+	CDC CDCType `json:"cdc"`
+	Audit AuditType `json:"audit"`
+	Auth AuthType `json:"auth"`
+	Limits LimitsType `json:"limits"`
+	TLS TLSType `json:"tls"`
+	NodeTLS NodeTLSType `json:"nodetls"`
+	Metrics MetricsType `json:"metrics"`
+	Log LogType `json:"log"`
+	Tracing TracingType `json:"tracing"`
+	Transport TransportType `json:"transport"`
+	Partition PartitionType `json:"partition"`
+	DeleteTimeOut int `json:"deletetimeout"`
+	SweepInterval int `json:"sweepinterval"`
+	Methods []string `json:"methods"`
+}
+
+/* The authentication of a request. The signature is the hex encoded HMAC-SHA256, keyed with the secret of the user, of the
+ * function name, the user, the timestamp, the nonce and the JSON of the input, each on its own line.
+ * Timestamp: The time the request was signed in seconds since 1970.
+ * Nonce: A random value that is never used twice, so that a request cannot be replayed.*/
+type AuthToken struct{
+	User string `json:"user"`
+	Timestamp int64 `json:"timestamp"`
+	Nonce string `json:"nonce"`
+	Signature string `json:"signature"`
+}
+
+/* The part of the input of a request that is signed.*/
+type signedbody struct{
+	Params []interface{} `json:"params"`
+	TTL *int64 `json:"ttl,omitempty"`
+	Override bool `json:"override,omitempty"`
+	ExpectedVersion *int64 `json:"expectedVersion,omitempty"`
+}
+
+/* The JSON message structure of the input passed to the function.
+ * Method: The function to be called.
+ * Params: The input json object that is passed as an argument to the function.
+ * TTL: The optional time to live of the triplet in seconds for the insert functions. Zero keeps the triplet forever.
+ * Override: Allows an administrator to update, delete or chmod triplets that are read only or append only.
+ * ExpectedVersion: The version the triplet must have for insertOrUpdate to succeed, zero if the triplet must not exist.
+ * Auth: The authentication of the request, it is needed when the server config file has users.
+ * Client, User: The address of the client and the authenticated user, they are set by the server and cannot be passed in the message.
+ * Id: The unique id refers to the transaction between the client and the server.*/
+type JsonMessage struct{
+	Method string 	`json:"method"`
+	Params []interface{} `json:"params"`
+	Portno int `json:"port"`
+	TTL *int64 `json:"ttl,omitempty"`
+	Override bool `json:"override,omitempty"`
+	ExpectedVersion *int64 `json:"expectedVersion,omitempty"`
+	Auth *AuthToken `json:"auth,omitempty"`
+	Traceparent string `json:"traceparent,omitempty"`
+	Client string `json:"-"`
+	User string `json:"-"`
+	RequestID string `json:"-"`
+	trace *trace
+}
+
+/* The json result structure that will be displayed to the user after the completion
+ * of the insert and insertOrUpdate functions.
+ * Result: Indicates the successful or unsuccessful completion of the function.
+ * Version: The version of the inserted or updated triplet.
+ * Id: The unique id refers to the transaction between the client and server.
+ * Error: The error that is returned by the function call. */
+type JsonResultInsert struct{
+	Result bool `json:"result"`
+	Version int64 `json:"version,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The json result structure that will be displayed to the user after the completion
+ * of the compareAndSwap function.
+ * Result: Indicates the successful or unsuccessful completion of the function.
+ * Version: The new version of the triplet.
+ * Error: The error that is returned by the function call. */
+type JsonResultCAS struct{
+	Result bool `json:"result"`
+	Version int64 `json:"version,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the lookup function.
+ * Result: Indicates the return of the params identified by the key and the relationship.
+ * Versions: The version of every returned triplet, in the same order as the result.
+ * Id: The unique id refers to the transaction between the client and server.
+ * Error: The error that is returned by the remote function call. */
+type JsonResultLookUp struct{
+	Result [][]string `json:"result"`
+	Versions []int64 `json:"versions,omitempty"`
+	Error string `json:"error"`
+}
+
+/* The json result structure that will be displayed to the user after the completion
+ * of the listKey function.
+ * Result: Identifies the list of unique keys returned by the function.
+ * Id: The unique id refers to the transaction between the client and server.
+ * Error: The error that is returned by the remote function call. */
+type JsonListKeys struct{
+	Result []string `json:"result"`
+	Error string `json:"error"`
+}
+
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the listID function.
+ * Result: Identifies the list of unique key and relationship combination returned by the listID function.
+ * Id: The unique id refers to the transaction between the client and server.
+ * Error: The error that is returned by the remote function call. */
+type JsonListIDs struct{
+	Result [][]string `json:"result"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the topology function.
+ * Result: The topology of the whole Chord ring.
+ * Dot: The same topology rendered in the Graphviz DOT language when it is requested.
+ * Error: The error that is returned by the remote function call. */
+type JsonTopology struct{
+	Result RingTopology `json:"result"`
+	Dot string `json:"dot,omitempty"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to describe the topology of the whole Chord ring.
+*RingSize: The number of positions in the Chord ring.
+*Nodes: The nodes of the ring sorted by their position in the ring.*/
+type RingTopology struct{
+	RingSize int `json:"ringSize"`
+	Nodes []TopologyNode `json:"nodes"`
+}
+
+/*This structure is used to describe a single node of the Chord ring.
+*ID: The position of the node in the Chord ring.
+*Port: The port number the node is listening on.
+*Successor, Predecessor: The port numbers of the successor and predecessor nodes.
+*Fingers: The finger table of the node in the order of its entries.
+*Keys: The number of triplets stored on the node.*/
+type TopologyNode struct{
+	ID int `json:"id"`
+	Port int `json:"port"`
+	Successor int `json:"successor"`
+	Predecessor int `json:"predecessor"`
+	Fingers []FingerEntry `json:"fingers"`
+	Keys int `json:"keys"`
+}
+
+/*This structure is used to describe a single entry of a finger table.
+*Start: The ring position the finger entry starts at.
+*Node: The port number of the successor of the start position.*/
+type FingerEntry struct{
+	Start int `json:"start"`
+	Node int `json:"node"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the verify function.
+ * Result: The report of the consistency check of the Chord ring.
+ * Error: The error that is returned by the remote function call. */
+type JsonVerify struct{
+	Result VerifyReport `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to report the consistency of the Chord ring.
+*Nodes: The number of nodes visited while walking the ring along the successor links.
+*BrokenLinks: The successor and predecessor links that do not agree with the ring.
+*IncorrectFingers: The finger entries that do not point to the successor of their start position.
+*MisplacedKeys: The triplets that are stored on a node other than their owner.
+*UnderReplicated: The triplets whose owner does not hold a copy. The ring keeps a single copy of each triplet.
+*Repaired: The number of misplaced triplets moved to their owner when the repair mode is used.
+*Consistent: Indicates whether no problem was found.*/
+type VerifyReport struct{
+	Nodes int `json:"nodes"`
+	BrokenLinks []RingIssue `json:"brokenLinks"`
+	IncorrectFingers []RingIssue `json:"incorrectFingers"`
+	MisplacedKeys []KeyIssue `json:"misplacedKeys"`
+	UnderReplicated []KeyIssue `json:"underReplicated"`
+	Repaired int `json:"repaired"`
+	Consistent bool `json:"consistent"`
+}
+
+/*This structure is used to describe a problem with the links or the finger table of a node.*/
+type RingIssue struct{
+	Node int `json:"node"`
+	Detail string `json:"detail"`
+}
+
+/*This structure is used to describe a triplet that is not stored on its rightful owner.
+*Node: The port number of the node that stores the triplet.
+*Owner: The port number of the node that should store the triplet.*/
+type KeyIssue struct{
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Node int `json:"node"`
+	Owner int `json:"owner"`
+}
+
+/*The structure of the DICT3 file.
+*Key: Refers to the key value of the parameter.
+*Relationship: Refers to the relationship value of the parameter. Key and Relationship value together identifies an unique row of the DICT3 file.
+*Value: Refers to the value of the argument passed to the function. It is a JSON object. */
+type DICT3format struct{
+	Key,Relationship string
+	Value string
+}
+
+/*This structure is used to persist a single triplet together with its metadata. The size is given in bytes and the timestamps
+*in RFC3339, the older "NKB" sizes and "01/02/2006, 15:04:05" timestamps are still accepted when a record is read.
+*A missing TTL is defaulted from the deletetimeout of the server and a missing version is treated as the first version.
+*A record with a deleted time is the tombstone of a triplet that was deleted or expired, which only keeps its key and version.*/
+type DICT3record struct{
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Value string `json:"value"`
+	Size string `json:"size"`
+	Created string `json:"created"`
+	Modified string `json:"modified"`
+	Accessed string `json:"accessed"`
+	Permission string `json:"permission"`
+	TTL *int64 `json:"ttl,omitempty"`
+	Version int64 `json:"version,omitempty"`
+	Creator string `json:"creator,omitempty"`
+	Deleted string `json:"deleted,omitempty"`
+}
+
+/*This structure is used to define the metadata of a triplet that is returned by the stat function.
+*Size: The size of the value in bytes.
+*Created, Modified, Accessed: The timestamps of the triplet in RFC3339, Modified is empty if the triplet was never updated.
+*Owner, OwnerID: The port no and the ring position of the node that stores the triplet.
+*Version: The version of the triplet, it starts at 1 and is incremented on every update.*/
+type EntryStat struct{
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Size int64 `json:"size"`
+	Created string `json:"created"`
+	Modified string `json:"modified,omitempty"`
+	Accessed string `json:"accessed"`
+	Permission string `json:"permission"`
+	TTL int64 `json:"ttl"`
+	Owner int `json:"owner"`
+	OwnerID int `json:"ownerId"`
+	Version int64 `json:"version"`
+}
+
+/*This structure is used to return the output of the stat function.*/
+type JsonStat struct{
+	Result EntryStat `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to define a single operation of a transaction.
+*Op: Refers to the operation and is either "put" or "delete".
+*Value, Permission, TTL: The new value, permission and time to live of the triplet for a put. A put without a permission or
+*ttl keeps the ones of an existing triplet.
+*ExpectedVersion: The version the triplet must have for the transaction to commit, zero if the triplet must not exist.*/
+type TxOp struct{
+	Op string `json:"op"`
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Value string `json:"value,omitempty"`
+	Permission string `json:"permission,omitempty"`
+	TTL *int64 `json:"ttl,omitempty"`
+	ExpectedVersion *int64 `json:"expectedVersion,omitempty"`
+}
+
+/*This structure is used to return the output of the transaction function.
+*Result: Indicates whether the transaction has been committed.
+*ID: The id of the transaction.
+*Versions: The new version of the triplet of every operation, zero for a delete.*/
+type JsonTransaction struct{
+	Result bool `json:"result"`
+	ID string `json:"id,omitempty"`
+	Versions []int64 `json:"versions,omitempty"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to return the output of the describe function.
+*Result: The functions that can be called at the server.*/
+type JsonDescribe struct{
+	Result []string `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to return the output of the quota function.
+*Client: The client the quotas and rate limits are reported for.
+*Result: The storage quotas that apply to the client with their usage.
+*Rates: The rate limits of the client with the calls it can make at once.*/
+type JsonQuota struct{
+	Client string `json:"client"`
+	Result []QuotaUsage `json:"result"`
+	Rates []RateUsage `json:"rates"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to return a storage quota and its usage.*/
+type QuotaUsage struct{
+	Prefix string `json:"prefix"`
+	Client string `json:"client"`
+	Entries int `json:"entries"`
+	MaxEntries int `json:"maxEntries"`
+	Bytes int64 `json:"bytes"`
+	MaxBytes int64 `json:"maxBytes"`
+}
+
+/*This structure is used to return a rate limit of a client, the method is empty for the limit of all the calls.*/
+type RateUsage struct{
+	Method string `json:"method"`
+	Rate float64 `json:"rate"`
+	Burst int `json:"burst"`
+	Tokens float64 `json:"tokens"`
+}
+
+/*This structure is used to report a node in the output of the ping function.
+*Port, ID: The port no and the ring position of the node.
+*Uptime: The seconds since the node was started.*/
+type NodePing struct{
+	Port int `json:"port"`
+	ID int `json:"id"`
+	Uptime float64 `json:"uptime"`
+}
+
+/*This structure is used to report the successor or the predecessor of a node.
+*Alive: Whether the node is in the ring and accepts connections.*/
+type PeerHealth struct{
+	Port int `json:"port"`
+	ID int `json:"id"`
+	Alive bool `json:"alive"`
+	Error string `json:"error,omitempty"`
+}
+
+/*This structure is used to report the health of a node.
+*Status: "ok", or the reason the node is not healthy or not ready.
+*Keys: The number of triplets the node stores that have not expired.
+*LastStabilized: The last time the links of the node were set, empty if they never were.
+*HandoffComplete: Whether the keys the node owns have been handed to it when it joined the ring.
+*Healthy: Whether the node is in the ring and accepts connections.
+*Ready: Whether the node is healthy, has stabilized, has its keys and its successor and predecessor are alive.*/
+type NodeHealth struct{
+	NodePing
+	Status string `json:"status"`
+	Successor PeerHealth `json:"successor"`
+	Predecessor PeerHealth `json:"predecessor"`
+	Keys int `json:"keys"`
+	LastStabilized string `json:"lastStabilized"`
+	HandoffComplete bool `json:"handoffComplete"`
+	Healthy bool `json:"healthy"`
+	Ready bool `json:"ready"`
+}
+
+/*These structures are used to return the output of the ping function and of the health and ready functions.*/
+type JsonPing struct{
+	Result NodePing `json:"result"`
+	Error string `json:"error"`
+}
+
+type JsonHealth struct{
+	Result NodeHealth `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to return the output of the addNode function.
+*Result: The port nos of the new nodes.*/
+type JsonAddNode struct{
+	Result []int `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to describe a change of a triplet that is sent to the watchers of the triplet.
+*Op: Refers to the change and is "insert", "update", "delete", "expire" or "chmod", or "move" and "merge" when the triplet is
+*handed to another node by a join, a leave, a repair or a merge of the ring.
+*Value, Version: The new value and version of the triplet, or the last ones for a delete or an expiry.
+*Owner: The port no of the node that stores the triplet.*/
+type ChangeEvent struct{
+	Op string `json:"op"`
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	Value string `json:"value"`
+	Version int64 `json:"version"`
+	Owner int `json:"owner"`
+	Time string `json:"time"`
+}
+
+/*This structure is used to return the output of the watch and unwatch functions.
+*Result: The id of the new watch, or true when a watch is removed.*/
+type JsonWatch struct{
+	Result interface{} `json:"result"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to return the output of the poll function.
+*Result: The changes since the last poll, in the order they were made.
+*Dropped: The number of changes that were dropped because the watch was not polled often enough.*/
+type JsonPoll struct{
+	Result []ChangeEvent `json:"result"`
+	Dropped int `json:"dropped,omitempty"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to define a watch of a client on a key, a relationship or a (key, relationship) pair. An empty key
+*or relationship matches every key or relationship.
+*events: The changes that have not been polled yet.
+*notify: Wakes up a poll that is waiting for changes.
+*polled: The time the watch was last polled, watches that are not polled for watchexpiry are removed.*/
+type watch struct{
+	key string
+	relation string
+	events []ChangeEvent
+	dropped int
+	notify chan bool
+	polled time.Time
+}
+
+/*This structure is used to describe a single entry of the change log of a node.
+*Seq: The sequence number of the change, it is incremented by one for every change of the node.
+*Op: Refers to the change and is "insert", "update", "delete", "expire" or "chmod", or "move" and "merge" when the triplet is
+*handed to another node, in which case the node that hands it logs the change with only the old value.
+*OldValue, NewValue: The value of the triplet before and after the change, missing for an insert and for a delete or an expiry.
+*OldVersion, NewVersion: The version of the triplet before and after the change, zero when the value is missing.*/
+type ChangeRecord struct{
+	Seq int64 `json:"seq"`
+	Op string `json:"op"`
+	Key string `json:"key"`
+	Relationship string `json:"relationship"`
+	OldValue *string `json:"oldValue,omitempty"`
+	NewValue *string `json:"newValue,omitempty"`
+	OldVersion int64 `json:"oldVersion,omitempty"`
+	NewVersion int64 `json:"newVersion,omitempty"`
+	Time string `json:"time"`
+}
+
+/*This structure is used to return the output of the changes function.
+*Result: The changes of the node from the requested sequence number on.
+*Node: The port no of the node.
+*First, Last: The sequence numbers of the oldest and the newest change that the node still keeps.
+*Truncated: Indicates that changes after the requested sequence number have already been dropped by the retention of the change log.*/
+type JsonChanges struct{
+	Result []ChangeRecord `json:"result"`
+	Node int `json:"node"`
+	First int64 `json:"first"`
+	Last int64 `json:"last"`
+	Truncated bool `json:"truncated,omitempty"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to define the change log of a node.
+*file: The open file of the change log.
+*seq: The sequence number of the last change.
+*entries: The changes that are kept by the retention of the change log.
+*written: The number of changes in the file, the file is rewritten when it holds twice as many changes as are kept.*/
+type changelog struct{
+	file *os.File
+	seq int64
+	entries []ChangeRecord
+	written int
+}
+
+/*This structure is used to describe a single entry of the audit log.
+*Op: The function that was called.
+*Params, Override: The input of the function.
+*Client, User: The address of the client that called the function and the authenticated user.
+*Node: The port no of the node the client is connected to.
+*Owners: The port nos of the nodes that own the triplets named in the input.
+*Result: "ok" or "error", the error itself is in Error.
+*LatencyMs: The time the function took in milliseconds.*/
+type AuditRecord struct{
+	Time string `json:"time"`
+	Op string `json:"op"`
+	Params []interface{} `json:"params"`
+	Override bool `json:"override,omitempty"`
+	Client string `json:"client"`
+	User string `json:"user,omitempty"`
+	Request string `json:"request,omitempty"`
+	Node int `json:"node"`
+	Owners []int `json:"owners,omitempty"`
+	Result string `json:"result"`
+	Error string `json:"error,omitempty"`
+	LatencyMs float64 `json:"latencyMs"`
+}
+
+/*This structure is used to keep a request that is audited until its response is written.*/
+type auditrequest struct{
+	method string
+	input *JsonMessage
+	start time.Time
+}
+
+/*This structure is used to serve a client connection. It wraps the JSON-RPC codec of the connection, tells the functions the
+*address of the client, counts the calls in the metrics of the node and writes the calls of the functions that change the ring
+*to the audit log.*/
+type nodeCodec struct{
+	rpc.ServerCodec
+	port int
+	client string
+	lock sync.Mutex
+	seq uint64
+	method string
+	pending map[uint64]*auditrequest
+}
+
+/*This structure is used to define the record of the coordinator log of a transaction. The record is written once all the
+*participants have prepared and the transaction is committed, so that the commit can be completed after a crash.
+*Writes: The changes prepared by the participants, in the order of the operations.*/
+type txlogentry struct{
+	ID string `json:"id"`
+	Coordinator int `json:"coordinator"`
+	State string `json:"state"`
+	Writes []walentry `json:"writes"`
+}
+
+/*This structure is used to describe a single entry of the write-ahead log of a node.
+*Op: Refers to the operation that is logged and is either "put", "delete", which leaves the tombstone in the record, or "drop",
+*which removes a triplet that was handed to another node.
+*Record: The triplet that is stored or deleted.*/
+type walentry struct{
+	Op string `json:"op"`
+	Record DICT3record `json:"record"`
+}
+
+/*This structure is used to define the durable storage of a single node.
+*dir: The directory holding the snapshot and the write-ahead log of the node.
+*wal: The open write-ahead log of the node.*/
+type nodestore struct{
+	dir string
+	wal *os.File
+}
+
+/*This structure is used to report the result of loading the DICT3 file at startup.
+*Loaded: The number of triplets restored onto the ring.
+*Skipped: The number of triplets that were already present on the ring, e.g. recovered from the write-ahead log.
+*Rejected: The lines of the file that could not be loaded and the reason for it.*/
+type LoadReport struct{
+	Loaded int
+	Skipped int
+	Rejected []RejectedRow
+}
+
+/*This structure is used to describe a line of the DICT3 file or an imported record that could not be loaded.*/
+type RejectedRow struct{
+	Line int `json:"line"`
+	Reason string `json:"reason"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the export function.
+ * Result: The page of triplets sorted by key and relationship.
+ * Total: The total number of triplets stored on the ring.
+ * More: Whether there are triplets after the page.
+ * Error: The error that is returned by the remote function call. */
+type JsonExport struct{
+	Result []DICT3record `json:"result"`
+	Total int `json:"total"`
+	More bool `json:"more"`
+	Error string `json:"error"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the import function.
+ * Result: The number of triplets stored on the ring.
+ * Rejected: The records of the batch that could not be imported, identified by their index in the batch.
+ * Error: The error that is returned by the remote function call. */
+type JsonImport struct{
+	Result int `json:"result"`
+	Rejected []RejectedRow `json:"rejected"`
+	Error string `json:"error"`
+}
+
+/*This structure is used to describe an archive of the whole ring written by the snapshot function.
+*Version: The version of the archive format.
+*Created: The time the snapshot was taken.
+*RingSize: The number of positions in the Chord ring.
+*Partitions: The partition of every node of the ring at the time of the snapshot.*/
+type ArchiveManifest struct{
+	Version int `json:"version"`
+	Created string `json:"created"`
+	RingSize int `json:"ringSize"`
+	Partitions []ArchivePartition `json:"partitions"`
+}
+
+/*This structure is used to describe the partition of a single node in an archive.
+*File: The name of the JSON-lines file holding the triplets of the node.
+*Entries: The number of triplets in the file.
+*Checksum: The SHA-256 checksum of the file.*/
+type ArchivePartition struct{
+	ID int `json:"id"`
+	Port int `json:"port"`
+	Successor int `json:"successor"`
+	Predecessor int `json:"predecessor"`
+	File string `json:"file"`
+	Entries int `json:"entries"`
+	Checksum string `json:"checksum"`
+}
+
+/* The JSON result structure that will be displayed to the user after the completion
+ * of the snapshot and restore functions.
+ * Result: The directory of the archive.
+ * Nodes: The number of partitions in the archive.
+ * Entries: The number of triplets written to or restored from the archive.
+ * Error: The error that is returned by the remote function call. */
+type JsonArchive struct{
+	Result string `json:"result"`
+	Nodes int `json:"nodes"`
+	Entries int `json:"entries"`
+	Error string `json:"error"`
+}
+
+/*This structure is used when there is no need to display any output JSON message to the user.*/
+type NoOutput struct {
+	Error string
+}
+
+/*This structure is a span of a trace in the OpenTelemetry (OTLP) JSON format. The times are nanoseconds since 1970.
+*Kind: 1 for the work done within a node and 2 for the request served by the node the client is connected to.*/
+type Span struct{
+	TraceID string `json:"traceId"`
+	SpanID string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId,omitempty"`
+	Name string `json:"name"`
+	Kind int `json:"kind"`
+	StartTimeUnixNano string `json:"startTimeUnixNano"`
+	EndTimeUnixNano string `json:"endTimeUnixNano"`
+	Attributes []SpanAttribute `json:"attributes"`
+	Status SpanStatus `json:"status"`
+	start time.Time
+	end time.Time
+}
+
+/*This structure is an attribute of a span or of the resource of a trace, the integer values are strings as in OTLP JSON.*/
+type SpanAttribute struct{
+	Key string `json:"key"`
+	Value SpanValue `json:"value"`
+}
+
+type SpanValue struct{
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue *string `json:"intValue,omitempty"`
+}
+
+/*This structure is the status of a span, the code is 1 when it succeeded and 2 when it failed.*/
+type SpanStatus struct{
+	Code int `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+/*These structures are the envelope of the spans of a trace in the OTLP JSON format.*/
+type TraceExport struct{
+	ResourceSpans []ResourceSpans `json:"resourceSpans"`
+}
+
+type ResourceSpans struct{
+	Resource TraceResource `json:"resource"`
+	ScopeSpans []ScopeSpans `json:"scopeSpans"`
+}
+
+type TraceResource struct{
+	Attributes []SpanAttribute `json:"attributes"`
+}
+
+type ScopeSpans struct{
+	Scope TraceScope `json:"scope"`
+	Spans []*Span `json:"spans"`
+}
+
+type TraceScope struct{
+	Name string `json:"name"`
+}
+
+/*The trace of a request. The root span is the request served by the node the client is connected to, or the call served by a node
+*that is called by another node for the request, and every other span is a child of it or of another span of the trace.*/
+type trace struct{
+	id string
+	root *Span
+	spans []*Span
+}
+
+/*The metrics of a node.
+*requests: The number of calls of every function, by function and result.
+*durations: The latency of the calls of every function.
+*hops: The number of finger hops of the successor lookups that started at the node.
+*changes: The number of changes of the triplets of the node by change, the expirations counting the deletions of the purge.
+*successorchanges, fingerchanges: The number of times the successor or a finger of the node has changed.
+*consumers: The last sequence number of the change log every consumer has read.*/
+type nodemetrics struct{
+	requests map[[2]string]uint64
+	durations map[string]*histogram
+	hops *histogram
+	changes map[string]uint64
+	successorchanges uint64
+	fingerchanges uint64
+	consumers map[string]int64
+}
+
+/*A histogram of observations with the upper bounds of its buckets.*/
+type histogram struct{
+	bounds []float64
+	counts []uint64
+	sum float64
+	count uint64
+}
+
+/*The token bucket of a rate limit of a client, refilled at the rate of the limit since the last call.*/
+type bucket struct{
+	tokens float64
+	last time.Time
+}
+
+/*The usage of a storage quota by the triplets of a node. The quota is its index in the limits config and the client is the
+*creator of the triplets for a quota that gives every client its own quota, empty otherwise.*/
+type quotacounter struct{
+	quota int
+	client string
+}
+
+/*The number of triplets and bytes of values that count against a storage quota.*/
+type quotacount struct{
+	entries int
+	bytes int64
+}
+
+/*A change of a triplet that is checked against the storage quotas, the value is nil for a delete.*/
+type quotachange struct{
+	k datakey
+	v *datavalue
+}
+
+/*This structure is used to define the "key" components of the dictionary.*/
+type datakey struct{
+	key string
+	relation string
+}
+
+/*This structure is used to define the "value" components of the dictionary.
+*The size is kept in bytes and the timestamps in UTC, modified is the zero time if the triplet was never updated.
+*The triplet expires once ttl has passed since it was last accessed, a ttl of zero never expires.
+*The version starts at 1 and is incremented every time the triplet is updated, a triplet inserted again after it was deleted or
+*expired continues after the version of its tombstone.*/
+type datavalue struct{
+	content string
+	size int64
+	created time.Time
+	modified time.Time
+	accessed time.Time
+	permission string
+	ttl time.Duration
+	version int64
+	creator string
+}
+
+/*This structure is used to define the tombstone a triplet leaves when it is deleted or expires, so that its versions never
+*start again at 1 and a client cannot mistake a new triplet for the one it has read before.
+*version: The version of the deletion, one more than the last version of the triplet.
+*deleted: The time the triplet was deleted.*/
+type tombstone struct{
+	version int64
+	deleted time.Time
+}
+
+/*This structure is used to define all of the components of each server instance.
+*prepared: The changes the node has prepared for the transactions that are not committed yet.
+*locks: The triplets locked by those transactions, mapped to the id of the transaction.
+*started: The time the node was started.
+*stabilized: The last time the successor, the predecessor and the fingers of the node were set.
+*handedoff: Whether the keys the node owns have been handed to it when it joined the ring.
+*successors: The nodes that follow the node in the ring, which stand in for the successor when it fails.
+*members: The nodes the node has seen in its ring, with the last time they answered, which the node probes.
+*tombstones: The tombstones of the triplets the node owns that were deleted or expired.*/
+type Server struct {
+	portno int
+	successor int
+	predecessor int
+	fingertable map[int]int
+	data map[datakey]datavalue
+	prepared map[string][]walentry
+	locks map[datakey]string
+	started time.Time
+	stabilized time.Time
+	handedoff bool
+	successors []int
+	members map[int]time.Time
+	tombstones map[datakey]tombstone
+}
+
+/*Refers to the functions the Chord nodes call on each other over the transport of the nodes. Every function takes the ring lock,
+*which the calling node releases for the time of the call.*/
+type Node int
+
+/*This structure is used to return the routing table of a node to the node that looks up a key.
+*Port, ID: The port no and the ring position of the node.
+*Routes: The successor, the successor list and the fingers of the node with their ring positions.*/
+type NodeRoutes struct{
+	Port int `json:"port"`
+	ID int `json:"id"`
+	Routes []NodeRef `json:"routes"`
+}
+
+/*This structure is used to refer to a node by its port no and its ring position.*/
+type NodeRef struct{
+	Port int `json:"port"`
+	ID int `json:"id"`
+}
+
+/*This structure is used to hand triplets from one node to another.
+*Op: The change the nodes record for the handed triplets, "move" when it is empty.*/
+type NodeRecords struct{
+	Records []DICT3record `json:"records"`
+	Op string `json:"op,omitempty"`
+}
+
+/*This structure is used to ask a node to prepare its operations of a transaction.
+*ID: The id of the transaction.
+*Ops, Indices: The operations of the transaction and the indices of the operations owned by the node.
+*Override, Client: The override flag of the transaction and the client that creates the new triplets.*/
+type NodePrepare struct{
+	ID string `json:"id"`
+	Ops []TxOp `json:"ops"`
+	Indices []int `json:"indices"`
+	Override bool `json:"override,omitempty"`
+	Client string `json:"client"`
+}
+
+/*This structure is used to pass the changes of a transaction between its coordinator and a node that takes part in it.
+*ID: The id of the transaction.
+*Writes: The changes prepared by the node.*/
+type NodeWrites struct{
+	ID string `json:"id"`
+	Writes []walentry `json:"writes"`
+}
+
+/*This structure is used to return the output of the ping function of a node.
+*Port, ID: The port no and the ring position of the node.
+*Successor, Predecessor: The port nos of the successor and the predecessor of the node.*/
+type NodeInfo struct{
+	Port int `json:"port"`
+	ID int `json:"id"`
+	Successor int `json:"successor"`
+	Predecessor int `json:"predecessor"`
+}
+
+/*The ping function is used by a node to check that another node is reachable and to learn its position in the ring.
+*input: The port no of the node that is calling.
+*output: The node that answers.
+*error: It contains the error value of the function if any error is generated.*/
+func (n *Node) Ping(input *JsonMessage, output *NodeInfo) error {
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	port := int(*n)
+	server, ok := servermap[port]
+	if !ok {
+		return errors.New("Node error - The node "+strconv.Itoa(port)+" has left the ring")
+	}
+	*output = NodeInfo{port,ringposition(port),server.successor,server.predecessor}
+	return nil
+}
+
+/*The routes function is used by a node that looks up a key to learn where the node can send it next. The nodes that are not
+*placed in the ring are left out.
+*The call is a span of a traced lookup when the calling node passes the traceparent of the lookup.
+*input: The port no of the node that is calling and the traceparent of the lookup.
+*output: The routing table of the node.
+*error: It contains the error value of the function if any error is generated.*/
+func (n *Node) Routes(input *JsonMessage, output *NodeRoutes) error {
+	defer nodespan(input,"chord.routes",int(*n))()
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	port := int(*n)
+	server, ok := servermap[port]
+	if !ok {
+		return errors.New("Node error - The node "+strconv.Itoa(port)+" has left the ring")
+	}
+	output.Port, output.ID = port, ringposition(port)
+	for _,v := range append(append([]int{server.successor},server.successors...),fingervalues(server.fingertable)...) {
+		if pos := ringposition(v); pos >= 0 {
+			output.Routes = append(output.Routes,NodeRef{v,pos})
+		}
+	}
+	return nil
+}
+
+/*The records function is used by a node that joins the ring to read the triplets of its successor, some of which it owns.
+*input: The port no of the node that is calling.
+*output: The triplets and the tombstones of the node.
+*error: It contains the error value of the function if any error is generated.*/
+func (n *Node) Records(input *JsonMessage, output *NodeRecords) error {
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	port := int(*n)
+	server, ok := servermap[port]
+	if !ok {
+		return errors.New("Node error - The node "+strconv.Itoa(port)+" has left the ring")
+	}
+	for k,v := range server.data {
+		output.Records = append(output.Records,torecord(k,v))
+	}
+	for k,t := range server.tombstones {
+		output.Records = append(output.Records,tombstonerecord(k,t))
+	}
+	return nil
+}
+
+/*The put function is used to hand triplets and tombstones to the node that owns them.
+*input: The triplets and the tombstones.
+*output: Nothing.
+*error: It contains the error value of the function if any error is generated.*/
+func (n *Node) Put(input *NodeRecords, output *NoOutput) error {
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	port := int(*n)
+	if _,ok := servermap[port]; !ok {
+		return errors.New("Node error - The node "+strconv.Itoa(port)+" has left the ring")
+	}
+	op := input.Op
+	if len(op) == 0 {
+		op = "move"
+	}
+	for _,r := range input.Records {
+		if len(r.Deleted) != 0 {
+			k, t := fromtombstone(r)
+			old, existed := servermap[port].data[k]
+			if existed && !buried(t,old) {
+				continue
+			}
+			if known, ok := servermap[port].tombstones[k]; ok && known.version >= t.version && !existed {
+				continue
+			}
+			if err := putTombstone(port,k,t); err != nil {
+				return err
+			}
+			if existed {
+				recordChange(op,port,k,&old,nil)
+			}
+			continue
+		}
+		k, v := fromrecord(r)
+		if err := storeEntry(op,port,k,v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*The delete function is used to remove the triplets and the tombstones a node has handed to their owner, or the copies of the
+*triplets a merge of the ring deletes. Only the removal of a copy that has been handed over is left to the owner to report.
+*input: The triplets, only their keys are used.
+*output: Nothing.
+*error: It contains the error value of the function if any error is generated.*/
+func (n *Node) Delete(input *NodeRecords, output *NoOutput) error {
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	port := int(*n)
+	if _,ok := servermap[port]; !ok {
+		return errors.New("Node error - The node "+strconv.Itoa(port)+" has left the ring")
+	}
+	op := input.Op
+	if len(op) == 0 {
+		op = "move"
+	}
+	for _,r := range input.Records {
+		k := datakey{r.Key,r.Relationship}
+		old, existed := servermap[port].data[k]
+		if err := dropEntry(port,k); err != nil {
+			return err
+		}
+		if existed && op == "move" {
+			logChange(op,port,k,&old,nil)
+		} else if existed {
+			recordChange(op,port,k,&old,nil)
+		}
+	}
+	return nil
+}
+
+/*The prepare function is used by the coordinator of a transaction to let a node check and lock its operations.
+*input: The transaction and the operations owned by the node.
+*output: The changes prepared by the node.
+*error: It contains the error value of the function if the node votes to abort.*/
+func (n *Node) Prepare(input *NodePrepare, output *NodeWrites) error {
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	port := int(*n)
+	if _,ok := servermap[port]; !ok {
+		return errors.New("Node error - The node "+strconv.Itoa(port)+" has left the ring")
+	}
+	staged, err := prepare(port,input.ID,input.Ops,input.Indices,input.Override,input.Client)
+	if err != nil {
+		return err
+	}
+	output.ID, output.Writes = input.ID, staged
+	return nil
+}
+
+/*The commit function is used by the coordinator of a transaction to let a node apply its changes once the commit is logged.
+*input: The transaction and the changes of the node from the coordinator log.
+*output: Nothing.
+*error: It contains the error value of the function if any error is generated.*/
+func (n *Node) Commit(input *NodeWrites, output *NoOutput) error {
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	return commit(int(*n),input.ID,input.Writes)
+}
+
+/*The abort function is used by the coordinator of a transaction to let a node drop its changes and release its locks.
+*input: The transaction, only its id is used.
+*output: Nothing.
+*error: It contains the error value of the function if any error is generated.*/
+func (n *Node) Abort(input *NodeWrites, output *NoOutput) error {
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	abort(int(*n),input.ID)
+	return nil
+}
+
+/*The permission modes of a triplet. A read-write triplet can be updated and deleted, a read only triplet cannot be changed
+*and an append only triplet can only be updated with a value that starts with its current value. Read only and append only
+*triplets can be changed by an administrator with the override flag.*/
+const (
+	permissionread = "R"
+	permissionreadwrite = "RW"
+	permissionappend = "A"
+)
+
+/*The format of the timestamps of a triplet that was used before the timestamps were written in RFC3339.*/
+const timestampformat = "01/02/2006, 15:04:05"
+
+/*These are all the variables and flags that are used.*/
+var serverconfig config
+var count int
+var count_of_server int
+var use_ports int
+var timediff time.Duration
+var servermap map[int]Server
+var ringmap map[int]int
+var ringval int
+var ringsize int
+var dupver []int
+var close map[int]bool
+var stores map[int]*nodestore
+var changelogs = make(map[int]*changelog)
+var auditlock sync.Mutex
+var nonces = make(map[string]time.Time)
+var noncelock sync.Mutex
+var ringlock sync.Mutex
+var memberlock sync.RWMutex
+var txlock sync.Mutex
+var watches = make(map[string]*watch)
+var watchcount int
+var watchlock sync.Mutex
+var clienttls *tls.Config
+var exposedmethods map[string]bool
+var methodlimits = make(map[string]RateType)
+var buckets = make(map[string]*bucket)
+var bucketlock sync.Mutex
+var quotacounts = make(map[int]map[quotacounter]quotacount)
+var metrics = make(map[int]*nodemetrics)
+var metricservers = make(map[int]*http.Server)
+var metricslock sync.Mutex
+var logger = slog.Default()
+var requestcount atomic.Uint64
+var traces chan []byte
+var tracelock sync.Mutex
+var nodetls *tls.Config
+var clienttransport Transport
+var nodetransport Transport
+var nodelisteners = make(map[int]net.Listener)
+var ringids = make(map[int]int)
+var nextringid int
+var unmerged [][]int
+
+/*The number of times a node calls another node before it gives up.*/
+const nodeattempts = 3
+
+/*The number of nodes that follow a node in its successor list.*/
+const successorlist = 3
+
+/*A watch keeps at most maxwatchevents changes that have not been polled and is removed when it is not polled for watchexpiry.*/
+const maxwatchevents = 1000
+const watchexpiry = 10*time.Minute
+
+
+/*The lookUp function is used to return the value referred by an existing ID(key + relationship).
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) LookUp(input JsonMessage, output *JsonResultLookUp) error {
+	defer lockring()()
+	params, err := stringparams(&input,0,1)
+	if err != nil {
+		return err
+	}
+	key, relationship := params[0], params[1]
+	if len(key) == 0 && len(relationship) == 0  {
+			return errors.New("Key error - Both Key and Relationship attributes cannot be null in the input")
+	}
+	var targetport int
+	datahash := DataHash(key,relationship)
+	if (len(key) != 0 && len(relationship) != 0) {
+		targetport = input.trace.findsuccessor(datahash,input.Portno)
+		dupver = dupver[:0]
+		defer input.trace.span("storage.get",targetport)()
+		storeddata := servermap[targetport].data
+		for k,v:= range storeddata {
+			if k.key == key && k.relation == relationship {
+				if expired(v,time.Now()) {
+					if err := deleteEntry(targetport,k); err != nil {
+						return err
+					}
+					recordChange("expire",targetport,k,&v,nil)
+					break
+				}
+				touchEntry(targetport,k,v)
+				output.Result = append(output.Result,[]string{key,relationship,v.content})
+				output.Versions = append(output.Versions,v.version)
+				return nil
+			}
+		}
+	}else if(len(key) != 0) {
+		for i := 0; i < 16; i++ {
+			hash := datahash + i;
+			targetport = input.trace.findsuccessor(hash,input.Portno)
+			dupver = dupver[:0]
+			end := input.trace.span("storage.scan",targetport)
+			storedata := servermap[targetport].data
+			for k,v := range storedata {
+				if k.key == key && !expired(v,time.Now()) {
+					touchEntry(targetport,k,v)
+					temp := []string{key,k.relation,v.content}
+					set := false
+					if len(output.Result) > 0 {
+						for i := range output.Result {
+							if((output.Result[i][0] == temp[0]) && (output.Result[i][1] == temp[1])) {
+									set = true
+							}
+						}
+					}
+					if (!set) {
+						output.Result = append(output.Result,temp)
+						output.Versions = append(output.Versions,v.version)
+					}
+				}
+			}
+			end()
+		}
+		return nil
+	} else {
+		for i := 0; i <= 112; i = i+16 {
+			hash := datahash + i;
+			targetport = input.trace.findsuccessor(hash,input.Portno)
+			dupver = dupver[:0]
+			end := input.trace.span("storage.scan",targetport)
+			storedata := servermap[targetport].data
+			for k,v := range storedata {
+				if k.relation == relationship && !expired(v,time.Now()) {
+					touchEntry(targetport,k,v)
+					temp := []string{k.key,relationship,v.content}
+					set := false
+					if len(output.Result) > 0 {
+						for i := range output.Result {
+							if((output.Result[i][0] == temp[0]) && (output.Result[i][1] == temp[1])) {
+									set = true
+							}
+						}
+					}
+					if(!set) {
+						output.Result = append(output.Result,temp)
+						output.Versions = append(output.Versions,v.version)
+					}
+				}
+			}
+			end()
+		}
+		return nil
+	}
+	return errors.New("Key and/or Relationship not found in DICT3")
+}
+
+/*The insert function is used to enter an unique triplet in the DICT3 file.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Insert(input *JsonMessage, output *JsonResultInsert) error {
+	defer lockring()()
+		params, err := stringparams(input,0,1,2,3)
+		if err != nil {
+			return err
+		}
+		key, relation := params[0], params[1]
+		if len(key) == 0 || len(relation) == 0  {
+			return errors.New("Key error - Key or Relationship attributes cannot be null in the input")
+		}
+		var targetport int
+		datahash := DataHash(key,relation)
+		targetport = input.trace.findsuccessor(datahash,input.Portno)
+		dupver = dupver[:0]
+		defer input.trace.span("storage.put",targetport)()
+		DICT3input := DICT3format{strings.TrimSpace(key),strings.TrimSpace(relation),params[2]}
+		storeddata := servermap[targetport].data
+		ttl, err := entryttl(input)
+		if err != nil {
+			return err
+		}
+		permission, err := parsepermission(params[3])
+		if err != nil {
+			return err
+		}
+		for k,v := range storeddata {
+			if k.key == DICT3input.Key && k.relation == DICT3input.Relationship && !expired(v,time.Now()) {
+				return errors.New("Key error - Key and Relationship already present in DICT3. Use insertOrUpdate function to change values of existing key.")
+			}
+		}
+		now := time.Now().UTC()
+		k := datakey{DICT3input.Key,DICT3input.Relationship}
+		if err := checklock(targetport,k); err != nil {
+			return err
+		}
+		v := datavalue{DICT3input.Value,int64(len(DICT3input.Value)),now,time.Time{},now,permission,ttl,nextversion(targetport,k),identity(input)}
+		if err := checkquota([]quotachange{{k,&v}}); err != nil {
+			return err
+		}
+		if err := putEntry(targetport,k,v); err != nil {
+			return err
+		}
+		recordChange("insert",targetport,k,nil,&v)
+		output.Result = true
+		output.Version = v.version
+		return nil
+}
+
+/*The insertOrUpdate function is used to enter an unique triplet in the DICT3 file or update an existing ID(key + relationship) with a new value.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) InsertOrUpdate(input *JsonMessage, output *JsonResultInsert) error {
+	defer lockring()()
+	params, err := stringparams(input,0,1,2,3)
+	if err != nil {
+		return err
+	}
+	version, err := upsert(input,params[0],params[1],params[2],params[3],input.ExpectedVersion)
+	if err != nil {
+		return err
+	}
+	output.Result = true
+	output.Version = version
+	return nil
+}
+
+/*The compareAndSwap function is used to update an ID(key + relationship) only if it still has the version the client has read.
+*The params are the key, the relationship, the expected version, the new value and the optional permission. An expected version
+*of zero inserts the triplet only if it does not exist yet.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) CompareAndSwap(input *JsonMessage, output *JsonResultCAS) error {
+	defer lockring()()
+	expected, ok := intparam(input,2)
+	if !ok || expected < 0 {
+		return errors.New("Version error - The expected version is required and cannot be negative")
+	}
+	params, err := stringparams(input,0,1,3,4)
+	if err != nil {
+		return err
+	}
+	version, err := upsert(input,params[0],params[1],params[2],params[3],&expected)
+	if err != nil {
+		return err
+	}
+	output.Result = true
+	output.Version = version
+	return nil
+}
+
+/*This function is used to insert a triplet or to update an existing one for the insertOrUpdate and compareAndSwap functions.
+*When an expected version is given the triplet is only written if its stored version is the expected one, zero meaning that the
+*triplet must not exist, and a conflict error is returned otherwise. The caller must hold the ring lock.
+*input: The input JSON message, the key, relationship, value and permission of the triplet and the optional expected version.
+*output: The new version of the triplet and the error if any.*/
+func upsert(input *JsonMessage, key string, relation string, value string, permissionparam string, expected *int64) (int64, error) {
+	if len(key) == 0 || len(relation) == 0  {
+		return 0,errors.New("Key error - Key or Relationship attributes cannot be null in the input")
+	}
+	var targetport int
+	datahash := DataHash(key,relation)
+	targetport = input.trace.findsuccessor(datahash,input.Portno)
+	dupver = dupver[:0]
+	defer input.trace.span("storage.put",targetport)()
+	DICT3input := DICT3format{strings.TrimSpace(key),strings.TrimSpace(relation),value}
+	k := datakey{DICT3input.Key,DICT3input.Relationship}
+	if err := checklock(targetport,k); err != nil {
+		return 0,err
+	}
+	now := time.Now().UTC()
+	ttl, err := entryttl(input)
+	if err != nil {
+		return 0,err
+	}
+	permission, err := parsepermission(permissionparam)
+	if err != nil {
+		return 0,err
+	}
+	oldvalue, ok := servermap[targetport].data[k]
+	if ok && expired(oldvalue,time.Now()) {
+		ok = false
+	}
+	var current int64
+	if ok {
+		current = oldvalue.version
+	}
+	if expected != nil && *expected != current {
+		return 0,errors.New("Conflict error - The stored version of the triplet is "+strconv.FormatInt(current,10)+", expected "+strconv.FormatInt(*expected,10))
+	}
+	if !ok {
+		v := datavalue{DICT3input.Value,int64(len(DICT3input.Value)),now,time.Time{},now,permission,ttl,nextversion(targetport,k),identity(input)}
+		if err := checkquota([]quotachange{{k,&v}}); err != nil {
+			return 0,err
+		}
+		if err := putEntry(targetport,k,v); err != nil {
+			return 0,err
+		}
+		recordChange("insert",targetport,k,nil,&v)
+		return v.version,nil
+	}
+	if input.TTL == nil {
+		ttl = oldvalue.ttl
+	}
+	if len(strings.TrimSpace(permissionparam)) == 0 {
+		permission = permissionof(oldvalue)
+	}
+	if err := checkupdate(oldvalue,DICT3input.Value,permission,overrides(input)); err != nil {
+		return 0,err
+	}
+	v := datavalue{DICT3input.Value,int64(len(DICT3input.Value)),oldvalue.created,now,now,permission,ttl,oldvalue.version+1,oldvalue.creator}
+	if err := checkquota([]quotachange{{k,&v}}); err != nil {
+		return 0,err
+	}
+	if err := putEntry(targetport,k,v); err != nil {
+		return 0,err
+	}
+	recordChange("update",targetport,k,&oldvalue,&v)
+	return v.version,nil
+}
+
+/*The transaction function is used to apply a list of puts and deletes on triplets that can be stored on different nodes atomically.
+*The first param is the list of operations. The transaction is executed with two-phase commit: every node owning one of the triplets
+*checks the conditions of its operations and locks the triplets, and only if all of them have prepared is the commit decision logged
+*and the changes applied. Otherwise the prepared nodes are rolled back and none of the operations is applied. The node the client is
+*connected to coordinates the transaction and calls the other nodes over the transport of the nodes.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Transaction(input *JsonMessage, output *JsonTransaction) error {
+	txlock.Lock()
+	defer txlock.Unlock()
+	defer lockring()()
+	ops, err := txops(input)
+	if err != nil {
+		return err
+	}
+	if pending, err := recovertransaction(); err != nil {
+		return errors.New("Transaction error - The transaction "+pending+" could not be completed: "+err.Error())
+	}
+	id := strconv.Itoa(input.Portno)+"-"+strconv.FormatInt(time.Now().UnixNano(),36)
+	owners := make(map[int][]int)
+	ports := []int{}
+	for i,op := range ops {
+		targetport := input.trace.findsuccessor(DataHash(op.Key,op.Relationship),input.Portno)
+		dupver = dupver[:0]
+		if _,ok := owners[targetport]; !ok {
+			ports = append(ports,targetport)
+		}
+		owners[targetport] = append(owners[targetport],i)
+	}
+	sort.Ints(ports)
+
+	writes := make([]walentry,len(ops))
+	prepared := []int{}
+	for _,port := range ports {
+		end := input.trace.span("transaction.prepare",port)
+		var staged NodeWrites
+		err := ringcall(input.Portno,port,"Node.Prepare",&NodePrepare{id,ops,owners[port],overrides(input),identity(input)},&staged)
+		end()
+		if err == nil && len(staged.Writes) != len(owners[port]) {
+			err = errors.New("Transaction error - The node "+strconv.Itoa(port)+" prepared "+strconv.Itoa(len(staged.Writes))+" of "+strconv.Itoa(len(owners[port]))+" operations")
+		}
+		if err != nil {
+			aborttransaction(input.Portno,id,prepared)
+			return err
+		}
+		prepared = append(prepared,port)
+		for j,i := range owners[port] {
+			writes[i] = staged.Writes[j]
+		}
+	}
+	changes := []quotachange{}
+	for _,w := range writes {
+		k, v := fromrecord(w.Record)
+		if w.Op == "delete" {
+			changes = append(changes,quotachange{k,nil})
+		} else {
+			changes = append(changes,quotachange{k,&v})
+		}
+	}
+	if err := checkquota(changes); err != nil {
+		aborttransaction(input.Portno,id,prepared)
+		return err
+	}
+	if err := writetxlog(txlogentry{id,input.Portno,"commit",writes}); err != nil {
+		aborttransaction(input.Portno,id,prepared)
+		return errors.New("Storage error - The transaction could not be logged: "+err.Error())
+	}
+	//Once the decision is logged every participant has to commit, so a participant that fails does not stop the others. The
+	//triplets then stay locked and the logged transaction is completed before the next transaction or at the next start.
+	var failed error
+	for _,port := range ports {
+		staged := []walentry{}
+		for _,i := range owners[port] {
+			staged = append(staged,writes[i])
+		}
+		end := input.trace.span("transaction.commit",port)
+		err := ringcall(input.Portno,port,"Node.Commit",&NodeWrites{id,staged},&NoOutput{})
+		end()
+		if err != nil && failed == nil {
+			failed = err
+		}
+	}
+	if failed != nil {
+		return errors.New("Transaction error - The transaction "+id+" is committed but could not be applied on every node, it is completed before the next transaction: "+failed.Error())
+	}
+	aborttransaction(input.Portno,id,ports)
+	if err := os.Remove(txlogpath()); err != nil && !os.IsNotExist(err) {
+		return errors.New("Storage error - "+err.Error())
+	}
+	output.Result = true
+	output.ID = id
+	for _,w := range writes {
+		output.Versions = append(output.Versions,w.Record.Version)
+	}
+	return nil
+}
+
+/*The watch function is used to subscribe to the changes of a key, a relationship or a (key, relationship) pair. The params are
+*the key and the relationship, either of which can be empty. The changes are received with the poll function.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Watch(input *JsonMessage, output *JsonWatch) error {
+	params, err := stringparams(input,0,1)
+	if err != nil {
+		return err
+	}
+	key := strings.TrimSpace(params[0])
+	relationship := strings.TrimSpace(params[1])
+	if len(key) == 0 && len(relationship) == 0 {
+		return errors.New("Key error - Both Key and Relationship attributes cannot be null in the input")
+	}
+	watchlock.Lock()
+	defer watchlock.Unlock()
+	for id,w := range watches {
+		if time.Since(w.polled) > watchexpiry {
+			delete(watches,id)
+		}
+	}
+	watchcount++
+	id := strconv.Itoa(input.Portno)+"-"+strconv.Itoa(watchcount)
+	watches[id] = &watch{key,relationship,[]ChangeEvent{},0,make(chan bool,1),time.Now()}
+	output.Result = id
+	return nil
+}
+
+/*The poll function is used to receive the changes of a watch. The params are the id of the watch and the optional number of
+*seconds to wait for a change (default 30). The function returns at once if there are changes that have not been polled yet,
+*otherwise it waits until a change is made or the time is up, in which case the result is empty.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Poll(input *JsonMessage, output *JsonPoll) error {
+	id, err := stringparam(input,0)
+	if err != nil {
+		return err
+	}
+	wait := int64(30)
+	if seconds, ok := intparam(input,1); ok {
+		wait = seconds
+	}
+	if wait < 0 || wait > 300 {
+		return errors.New("Watch error - The poll timeout must be between 0 and 300 seconds")
+	}
+	watchlock.Lock()
+	w, ok := watches[id]
+	if !ok {
+		watchlock.Unlock()
+		return errors.New("Watch error - Unknown watch "+strconv.Quote(id))
+	}
+	w.polled = time.Now()
+	if len(w.events) == 0 && wait > 0 {
+		//A wake up left over from changes that an earlier poll has already returned must not end this poll at once.
+		select {
+		case <-w.notify:
+		default:
+		}
+		watchlock.Unlock()
+		timer := time.NewTimer(time.Duration(wait)*time.Second)
+		select {
+		case <-w.notify:
+		case <-timer.C:
+		}
+		timer.Stop()
+		watchlock.Lock()
+	}
+	output.Result = w.events
+	output.Dropped = w.dropped
+	w.events = []ChangeEvent{}
+	w.dropped = 0
+	w.polled = time.Now()
+	watchlock.Unlock()
+	return nil
+}
+
+/*The unwatch function is used to remove a watch. The param is the id of the watch.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Unwatch(input *JsonMessage, output *JsonWatch) error {
+	id, err := stringparam(input,0)
+	if err != nil {
+		return err
+	}
+	watchlock.Lock()
+	defer watchlock.Unlock()
+	w, ok := watches[id]
+	if !ok {
+		return errors.New("Watch error - Unknown watch "+strconv.Quote(id))
+	}
+	delete(watches,id)
+	select {
+	case w.notify <- true:
+	default:
+	}
+	output.Result = true
+	return nil
+}
+
+/*The changes function is used to tail the change log of a node. The params are the port no of the node (the node the client is
+*connected to when it is zero or missing), the sequence number to start from and the maximum number of changes (default 1000).
+*A consumer passes the last sequence number it has seen plus one to receive the next changes in order.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Changes(input *JsonMessage, output *JsonChanges) error {
+	defer lockring()()
+	node := input.Portno
+	if port, ok := intparam(input,0); ok && port != 0 {
+		node = int(port)
+	}
+	from, _ := intparam(input,1)
+	limit := int64(1000)
+	if l, ok := intparam(input,2); ok {
+		limit = l
+	}
+	if limit <= 0 {
+		return errors.New("Changes error - The limit must be positive")
+	}
+	changes, ok := changelogs[node]
+	if !ok {
+		return errors.New("Changes error - There is no server with the port no "+strconv.Itoa(node))
+	}
+	changes.trim(time.Now())
+	output.Node = node
+	output.Result = []ChangeRecord{}
+	output.First = changes.seq+1
+	if len(changes.entries) > 0 {
+		output.First = changes.entries[0].Seq
+	}
+	output.Last = changes.seq
+	output.Truncated = from < output.First && from <= changes.seq
+	for _,r := range changes.entries {
+		if r.Seq >= from && int64(len(output.Result)) < limit {
+			output.Result = append(output.Result,r)
+		}
+	}
+	seen := from-1
+	if len(output.Result) > 0 {
+		seen = output.Result[len(output.Result)-1].Seq
+	}
+	observeconsumer(node,identity(input),seen)
+	return nil
+}
+
+/*The delete function is used to delete a unique triplet from DICT3.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Delete(input *JsonMessage, output *NoOutput) error {
+	defer lockring()()
+	params, err := stringparams(input,0,1)
+	if err != nil {
+		return err
+	}
+	if len(params[0]) == 0 || len(params[1]) == 0  {
+		return errors.New("Key error - Key or Relationship attributes cannot be null in the input")
+	}
+	var targetport int
+	key := strings.TrimSpace(params[0])
+	relationship := strings.TrimSpace(params[1])
+	datahash := DataHash(key,relationship)
+	targetport = input.trace.findsuccessor(datahash,input.Portno)
+	dupver = dupver[:0]
+	defer input.trace.span("storage.delete",targetport)()
+	storeddata := servermap[targetport].data
+	for k,v:= range storeddata {
+		if k.key == key && k.relation == relationship && !expired(v,time.Now()) {
+			if err := checklock(targetport,k); err != nil {
+				return err
+			}
+			if permissionof(v) != permissionreadwrite && !overrides(input) {
+				return errors.New("Permission Error - This value is "+permissionname(permissionof(v))+" and cannot be deleted. Use override to delete it.")
+			}
+			if err := deleteEntry(targetport,k); err != nil {
+				return err
+			}
+			recordChange("delete",targetport,k,&v,nil)
+			output.Error = " "
+			return nil
+		}
+	}
+	return errors.New("Key and/or Relationship not found in DICT3")
+}
+
+/*The chmod function is used to change the permission of an existing ID(key + relationship). The params are the key, the relationship
+*and the new permission. The permission of a read only or append only triplet can only be changed with the override flag.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Chmod(input *JsonMessage, output *NoOutput) error {
+	defer lockring()()
+	params, err := stringparams(input,0,1,2)
+	if err != nil {
+		return err
+	}
+	key := strings.TrimSpace(params[0])
+	relationship := strings.TrimSpace(params[1])
+	if len(key) == 0 || len(relationship) == 0 {
+		return errors.New("Key error - Key or Relationship attributes cannot be null in the input")
+	}
+	if len(strings.TrimSpace(params[2])) == 0 {
+		return errors.New("Permission Error - The new permission is required")
+	}
+	permission, err := parsepermission(params[2])
+	if err != nil {
+		return err
+	}
+	targetport := input.trace.findsuccessor(DataHash(key,relationship),input.Portno)
+	dupver = dupver[:0]
+	defer input.trace.span("storage.put",targetport)()
+	k := datakey{key,relationship}
+	v, ok := servermap[targetport].data[k]
+	if !ok || expired(v,time.Now()) {
+		return errors.New("Key and/or Relationship not found in DICT3")
+	}
+	if err := checklock(targetport,k); err != nil {
+		return err
+	}
+	if permissionof(v) != permissionreadwrite && !overrides(input) {
+		return errors.New("Permission Error - This value is "+permissionname(permissionof(v))+" and its permission cannot be changed. Use override to change it.")
+	}
+	old := v
+	v.permission = permission
+	v.version++
+	if err := putEntry(targetport,k,v); err != nil {
+		return err
+	}
+	recordChange("chmod",targetport,k,&old,&v)
+	output.Error = " "
+	return nil
+}
+
+/*The stat function is used to return the metadata of an existing ID(key + relationship) without reading its value.
+*The access time of the triplet is not changed.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Stat(input *JsonMessage, output *JsonStat) error {
+	defer lockring()()
+	if len(input.Params) < 2 {
+		return errors.New("Key error - Both Key and Relationship are required")
+	}
+	params, err := stringparams(input,0,1)
+	if err != nil {
+		return err
+	}
+	key := strings.TrimSpace(params[0])
+	relationship := strings.TrimSpace(params[1])
+	if len(key) == 0 || len(relationship) == 0 {
+		return errors.New("Key error - Key or Relationship attributes cannot be null in the input")
+	}
+	targetport := input.trace.findsuccessor(DataHash(key,relationship),input.Portno)
+	dupver = dupver[:0]
+	defer input.trace.span("storage.get",targetport)()
+	v, ok := servermap[targetport].data[datakey{key,relationship}]
+	if !ok || expired(v,time.Now()) {
+		return errors.New("Key and/or Relationship not found in DICT3")
+	}
+	output.Result = EntryStat{key,relationship,v.size,formattimestamp(v.created),formattimestamp(v.modified),formattimestamp(v.accessed),v.permission,int64(v.ttl/time.Second),targetport,ringposition(targetport),v.version}
+	return nil
+}
+
+/*The listKey function is used to return a list of unique key values from DICT3.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) ListKeys(input *JsonMessage, output *JsonListKeys) error {
+	defer lockring()()
+	keymap := make(map[datakey]struct{})
+	key := []string{}
+	for _,server := range servermap {
+		storeddata := server.data
+		for k,v := range storeddata {
+			if !expired(v,time.Now()) {
+				keymap[k] = struct{}{}
+			}
+		}
+		for k := range(keymap){
+			set := false
+			if len(key) >= 1 {
+				for i := range key {
+					if (key[i] == k.key){
+						set = true
+					}
+				}
+			}
+			if !set {
+				key = append(key,k.key)
+			}
+		}
+	}
+	output.Result = key
+	return nil
+}
+
+/*The listID function is used to return a list of unique IDs(key + relationship) from DICT3.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) ListIDs(input *JsonMessage, output *JsonListIDs) error {
+	defer lockring()()
+	id := [][]string{}
+	for _,server := range servermap {
+		storeddata := server.data
+		for k,v := range storeddata {
+			if expired(v,time.Now()) {
+				continue
+			}
+			tempid := []string{k.key,k.relation}
+			id = append(id,tempid)
+		}
+	}
+	output.Result = id
+	return nil
+}
+
+/*The purge function is used to remove stale entries from the dictionary which have not been accessed for specific time.
+*Expired entries are also removed by the background sweeper of every node, so calling it is only needed to expire them at once.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Purge(input *JsonMessage, output *NoOutput) error {
+	defer lockring()()
+	for port := range servermap {
+		if _,err := expireEntries(port,time.Now()); err != nil {
+			return err
+		}
+	}
+	output.Error = " "
+	return nil
+}
+
+/*The topology function is used to return the topology of the whole Chord ring.
+*input: This input refers to the JsonMessage structure. The optional first parameter selects the "json" or "dot" format.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Topology(input *JsonMessage, output *JsonTopology) error {
+	defer lockring()()
+	format, err := stringparam(input,0)
+	if err != nil {
+		return err
+	}
+	if len(format) == 0 {
+		format = "json"
+	}
+	format = strings.ToLower(format)
+	if format != "json" && format != "dot" {
+		return errors.New("Format error - The topology can only be exported as json or dot")
+	}
+	output.Result = BuildTopology()
+	if format == "dot" {
+		output.Dot = TopologyDOT(output.Result)
+	}
+	return nil
+}
+
+/*The verify function is used to check the consistency of the Chord ring. It walks the ring along the successor
+*links and checks the successor and predecessor links, the finger tables and the placement of every stored triplet.
+*input: This input refers to the JsonMessage structure. When the optional first parameter is true the misplaced triplets are moved to their owner.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Verify(input *JsonMessage, output *JsonVerify) error {
+	defer lockring()()
+	repair := false
+	if len(input.Params) > 0 {
+		repair,_ = input.Params[0].(bool)
+	}
+	if _,ok := servermap[input.Portno]; !ok {
+		return errors.New("Node error - The server is not part of the Chord ring")
+	}
+	output.Result = VerifyRing(input.Portno,repair)
+	return nil
+}
+
+/*The export function is used to return a page of all the triplets stored on the ring together with their metadata.
+*The triplets are sorted by key and relationship and a page starts after the key and relationship of the last triplet of the
+*previous page, so that a client can stream the whole ring page by page without skipping or repeating triplets that are
+*inserted or deleted in the meantime.
+*input: This input refers to the JsonMessage structure. The parameters are the key and the relationship of the last triplet
+*of the previous page, both empty for the first page, and the number of triplets in the page.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Export(input *JsonMessage, output *JsonExport) error {
+	defer lockring()()
+	params, err := stringparams(input,0,1)
+	if err != nil {
+		return err
+	}
+	after := datakey{params[0],params[1]}
+	limit := int64(1000)
+	if len(input.Params) > 2 {
+		var ok bool
+		if limit, ok = intparam(input,2); !ok || limit <= 0 {
+			return errors.New("Params error - The limit has to be a positive number")
+		}
+	}
+	keys := []datakey{}
+	values := make(map[datakey]datavalue)
+	now := time.Now()
+	for _,server := range servermap {
+		for k,v := range server.data {
+			if expired(v,now) {
+				continue
+			}
+			output.Total++
+			if len(after.key) != 0 || len(after.relation) != 0 {
+				if k.key < after.key || (k.key == after.key && k.relation <= after.relation) {
+					continue
+				}
+			}
+			keys = append(keys,k)
+			values[k] = v
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].key != keys[j].key {
+			return keys[i].key < keys[j].key
+		}
+		return keys[i].relation < keys[j].relation
+	})
+	if int64(len(keys)) > limit {
+		keys = keys[:limit]
+		output.More = true
+	}
+	output.Result = []DICT3record{}
+	for _,k := range keys {
+		output.Result = append(output.Result,torecord(k,values[k]))
+	}
+	return nil
+}
+
+/*The import function is used to store a batch of triplets with their metadata on the ring. Existing triplets
+*with the same key and relationship are replaced. A triplet that is locked by a transaction or that would exceed a quota
+*is rejected like an invalid record.
+*input: This input refers to the JsonMessage structure. The first parameter is the list of records to import.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Import(input *JsonMessage, output *JsonImport) error {
+	defer lockring()()
+	if len(input.Params) == 0 {
+		return errors.New("Params error - The records to import are missing")
+	}
+	raw, err := json.Marshal(input.Params[0])
+	if err != nil {
+		return err
+	}
+	var records []DICT3record
+	if err := json.Unmarshal(raw,&records); err != nil {
+		return errors.New("Params error - The records to import are not valid: "+err.Error())
+	}
+	output.Rejected = []RejectedRow{}
+	for i,r := range records {
+		completerecord(&r)
+		if err := validaterecord(r); err != nil {
+			output.Rejected = append(output.Rejected,RejectedRow{i,err.Error()})
+			continue
+		}
+		k,v := fromrecord(r)
+		k = datakey{strings.TrimSpace(k.key),strings.TrimSpace(k.relation)}
+		targetport := FindSuccessor(DataHash(k.key,k.relation),input.Portno)
+		dupver = dupver[:0]
+		if err := checklock(targetport,k); err != nil {
+			output.Rejected = append(output.Rejected,RejectedRow{i,err.Error()})
+			continue
+		}
+		if err := checkquota([]quotachange{{k,&v}}); err != nil {
+			output.Rejected = append(output.Rejected,RejectedRow{i,err.Error()})
+			continue
+		}
+		if next := nextversion(targetport,k); v.version < next {
+			v.version = next
+		}
+		if err := storeEntry("",targetport,k,v); err != nil {
+			return err
+		}
+		output.Result++
+	}
+	return nil
+}
+
+/*The snapshot function is used to capture the partition of every node together with the ring membership into a
+*new archive directory. The ring is locked while the archive is written, so the archive holds a single point in time.
+*input: This input refers to the JsonMessage structure. The optional first parameter is the name of the archive.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Snapshot(input *JsonMessage, output *JsonArchive) error {
+	defer lockring()()
+	name, err := stringparam(input,0)
+	if err != nil {
+		return err
+	}
+	if len(name) == 0 {
+		name = "ring-"+time.Now().UTC().Format("20060102T150405Z")
+	}
+	name = filepath.Base(name)
+	manifest, err := WriteArchive(filepath.Join(serverconfig.Storage.ArchiveDir,name))
+	if err != nil {
+		return errors.New("Snapshot error - "+err.Error())
+	}
+	output.Result = filepath.Join(serverconfig.Storage.ArchiveDir,name)
+	output.Nodes = len(manifest.Partitions)
+	for _,partition := range manifest.Partitions {
+		output.Entries += partition.Entries
+	}
+	return nil
+}
+
+/*The restore function is used to replace all the data of the ring with the data of an archive. The checksum of
+*every partition is verified before anything is changed, and every triplet is stored on its owner in the current
+*ring, which may have a different number of nodes than the ring the archive was taken from. The ring is locked for
+*the clients while the data of the nodes is swapped, and nothing is changed if any node cannot store its new data.
+*input: This input refers to the JsonMessage structure. The first parameter is the name of the archive in the archive directory.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Restore(input *JsonMessage, output *JsonArchive) error {
+	name, err := stringparam(input,0)
+	if err != nil {
+		return err
+	}
+	if len(name) == 0 {
+		return errors.New("Params error - The archive to restore is missing")
+	}
+	if !filepath.IsLocal(name) {
+		return errors.New("Params error - The archive has to be named by its path in the archive directory")
+	}
+	dir := filepath.Join(serverconfig.Storage.ArchiveDir,name)
+	manifest, records, err := ReadArchive(dir)
+	if err != nil {
+		return errors.New("Restore error - "+err.Error())
+	}
+	defer lockmembers()()
+	for _,server := range servermap {
+		for k,holder := range server.locks {
+			return errors.New("Transaction error - The triplet ("+k.key+", "+k.relation+") is locked by the transaction "+holder)
+		}
+	}
+	owners := make([]int,len(records))
+	for i,r := range records {
+		owners[i] = FindSuccessor(DataHash(r.Key,r.Relationship),input.Portno)
+		dupver = dupver[:0]
+		if _,ok := servermap[owners[i]]; !ok {
+			return errors.New("Restore error - The owner of the key "+r.Key+" cannot be reached")
+		}
+	}
+
+	//The data of every node is replaced at once: the new snapshots of all the nodes are written before any of them is installed.
+	now := time.Now().UTC()
+	restored := make(map[int]Server)
+	for port,server := range servermap {
+		server.data, server.tombstones = make(map[datakey]datavalue), make(map[datakey]tombstone)
+		for k,t := range servermap[port].tombstones {
+			server.tombstones[k] = t
+		}
+		for k,v := range servermap[port].data {
+			server.tombstones[k] = tombstone{v.version+1,now}
+		}
+		restored[port] = server
+	}
+	for i,r := range records {
+		k,v := fromrecord(r)
+		if next := nextversion(owners[i],k); v.version < next {
+			v.version = next
+		}
+		restored[owners[i]].data[k] = v
+		delete(restored[owners[i]].tombstones,k)
+	}
+	staged := []*nodestore{}
+	for port := range restored {
+		store, ok := stores[port]
+		if !ok {
+			continue
+		}
+		if err := store.stage(restored[port]); err != nil {
+			for _,s := range staged {
+				os.Remove(filepath.Join(s.dir,"snapshot.tmp"))
+			}
+			return errors.New("Restore error - Nothing has been restored: "+err.Error())
+		}
+		staged = append(staged,store)
+	}
+	for _,store := range staged {
+		if err := store.install(); err != nil {
+			return errors.New("Storage error - "+err.Error())
+		}
+	}
+	for port,server := range restored {
+		old := servermap[port]
+		servermap[port] = server
+		delete(quotacounts,port)
+		for k,v := range server.data {
+			countquota(port,k,v,1)
+		}
+		for k,v := range old.data {
+			if _,kept := server.data[k]; !kept {
+				recordChange("delete",port,k,&v,nil)
+			}
+		}
+		for k,v := range server.data {
+			if o,ok := old.data[k]; ok {
+				recordChange("update",port,k,&o,&v)
+			} else {
+				recordChange("insert",port,k,nil,&v)
+			}
+		}
+	}
+	output.Result = dir
+	output.Nodes = len(manifest.Partitions)
+	output.Entries = len(records)
+	return nil
+}
+
+/*The describe function is used by the clients to find out which functions the server provides, named as in the config file.
+*It can always be called.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Describe(input *JsonMessage, output *JsonDescribe) error {
+	output.Result = append(output.Result,"describe")
+	if len(serverconfig.Methods) != 0 {
+		output.Result = append(output.Result,serverconfig.Methods...)
+		return nil
+	}
+	for _,method := range dict3methods() {
+		if method != "Describe" {
+			name := []rune(method)
+			name[0] = unicode.ToLower(name[0])
+			output.Result = append(output.Result,string(name))
+		}
+	}
+	return nil
+}
+
+/*The ping function is used to check that a node answers. The optional param is the port no of the node, by default the node
+*of the client.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Ping(input *JsonMessage, output *JsonPing) error {
+	defer lockring()()
+	port := nodeparam(input)
+	server, ok := servermap[port]
+	if !ok {
+		return errors.New("Node error - The node "+strconv.Itoa(port)+" is not in the ring")
+	}
+	output.Result = NodePing{port,ringposition(port),time.Since(server.started).Seconds()}
+	return nil
+}
+
+/*The health function is used to report the health of a node: its uptime, whether its successor and predecessor are alive, the
+*number of keys it stores, when it was last stabilized and whether its keys have been handed to it. The optional param is the
+*port no of the node, by default the node of the client.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Health(input *JsonMessage, output *JsonHealth) error {
+	output.Result = nodehealth(nodeparam(input))
+	return nil
+}
+
+/*The ready function is used to check that a node has finished joining the ring. It reports the same as the health function
+*and fails when the node is not ready, with the reason.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Ready(input *JsonMessage, output *JsonHealth) error {
+	output.Result = nodehealth(nodeparam(input))
+	if !output.Result.Ready {
+		return errors.New("Node error - The node "+strconv.Itoa(output.Result.Port)+" is not ready: "+output.Result.Status)
+	}
+	return nil
+}
+
+/*The quota function is used to return the storage quotas and the rate limits of a client. The optional param is the client,
+*by default the client that calls the function.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Quota(input *JsonMessage, output *JsonQuota) error {
+	defer lockring()()
+	client, err := stringparam(input,0)
+	if err != nil {
+		return err
+	}
+	if len(client) == 0 {
+		client = identity(input)
+	} else if client != identity(input) && !admin(input) {
+		return errors.New("Auth error - Only an admin can read the quotas of another client")
+	}
+	output.Client = client
+	now := time.Now()
+	for i,q := range serverconfig.Limits.Quotas {
+		if len(q.Client) != 0 && q.Client != "*" && q.Client != client {
+			continue
+		}
+		count := quotausage(i,client)
+		output.Result = append(output.Result,QuotaUsage{q.Prefix,q.Client,count.entries,q.Entries,count.bytes,q.Bytes})
+	}
+	bucketlock.Lock()
+	defer bucketlock.Unlock()
+	if limit := serverconfig.Limits.Client; limit.Rate > 0 {
+		output.Rates = append(output.Rates,RateUsage{"",limit.Rate,limit.Burst,refill(client,limit,now).tokens})
+	}
+	methods := []string{}
+	for method := range methodlimits {
+		methods = append(methods,method)
+	}
+	sort.Strings(methods)
+	for _,method := range methods {
+		limit := methodlimits[method]
+		output.Rates = append(output.Rates,RateUsage{strings.TrimPrefix(method,"Dict3."),limit.Rate,limit.Burst,refill(client+"\n"+method,limit,now).tokens})
+	}
+	return nil
+}
+
+/*The addNode function is used to add new nodes to the Chord ring. The param is the number of nodes to add (default 1, at most 16).
+*The data of the successors of the new nodes is handed to them and the ring is stabilized.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) AddNode(input *JsonMessage, output *JsonAddNode) error {
+	defer lockmembers()()
+	n := int64(1)
+	if count, ok := intparam(input,0); ok {
+		n = count
+	}
+	if n < 1 || n > 16 {
+		return errors.New("Node error - The number of nodes to add must be between 1 and 16")
+	}
+	if len(ringmap)+int(n) > ringsize {
+		return errors.New("Node error - The Chord ring cannot hold more than "+strconv.Itoa(ringsize)+" nodes")
+	}
+	first := use_ports
+	err := newServerInstance(int(n))
+	for port := first; port < use_ports; port++ {
+		output.Result = append(output.Result,port)
+	}
+	if err != nil {
+		return errors.New("Node error - "+err.Error())
+	}
+	return nil
+}
+
+/*The shutdown function is used to shutdown the server process.
+*input: This input refers to the JsonMessage structure and has all the input arguments required by the function.
+*output: It refers to the pointer structure that provides the output of the function.
+*error: It contains the error value of the function if any error is generated.*/
+func (d *Dict3) Shutdown(input *JsonMessage, output *NoOutput) error {
+	defer lockmembers()()
+	if _,err := leavering(input.Portno); err != nil {
+		return err
+	}
+	close[input.Portno] = false
+	count++
+	if count == count_of_server {
+		log := logger.With("request",input.RequestID)
+		log.Info("all the active servers have been closed, saving all the data to the disk")
+		status := 0
+		if err := closestores(); err != nil {
+			log.Error("the storage could not be closed","error",err)
+			status = 1
+		}
+		if err := saveDICT3(); err != nil {
+			log.Error("the DICT3 file could not be saved","file",serverconfig.PersistentStorageContainer.File,"error",err)
+			status = 1
+		}
+		os.Exit(status)
+	}
+	return nil
+}
+
+/*The contains function is used to check whether a slice contains an integer or not.
+*input: This input refers to the input slice list and the element to check whether it is present in the list.
+*output: It refers to the boolean which indicates whether the element is present in the list or not.*/
+func contains(s []int, e int) bool {
+    for _, a := range s { if a == e { return true } }
+    return false
+}
+
+/*This function is used to find the successor of the given key from the starting portno. The lookup is iterative: the node asks
+*every node on the way for its routing table over the transport of the nodes and goes on to the closest node that precedes the
+*key until the key falls between a node and its successor. A node that does not answer is skipped, so the successor list and
+*the fingers stand in for a successor that has failed until the ring is stabilized. A lookup from a port no that is not in the
+*ring starts from the first node of the ring, and every node is visited at most once. The caller must hold the ring lock, which
+*is released while the other nodes are asked for their routing tables.
+*input: The input refers to the key for which the successor from the given port no.
+*output: The successor for the given key is returned, -1 if the ring is empty or the node cannot be reached.*/
+func FindSuccessor(key int,portno int) int{
+	successor, _ := lookup(key,portno,"")
+	return successor
+}
+
+/*This function is used to look up the successor of a key as FindSuccessor does and to return the number of hops of the lookup as well.
+*The traceparent of a traced lookup is passed to the nodes that are asked for their routing tables.
+*input: The key, the port no of the node the lookup starts from and the traceparent, empty if the lookup is not traced.
+*output: The successor of the key and the number of hops from one node to the next.*/
+func lookup(key int,portno int,traceparent string) (int, int) {
+	hops := 0
+	key = ((key%ringsize)+ringsize)%ringsize
+	port := portno
+	if _,ok := servermap[port]; !ok {
+		port = ringsuccessor(0)
+	}
+	if port < 0 {
+		return -1,hops
+	}
+	defer func() {
+		observehops(portno,hops)
+	}()
+	from := port
+	tables := make(map[int]*NodeRoutes)
+	routes := func(to int) *NodeRoutes {
+		if table, ok := tables[to]; ok {
+			return table
+		}
+		table := new(NodeRoutes)
+		if err := ringcall(from,to,"Node.Routes",&JsonMessage{Portno: from, Traceparent: traceparent},table); err != nil {
+			table = nil
+		}
+		tables[to] = table
+		return table
+	}
+	current := routes(port)
+	if current == nil {
+		return -1,hops
+	}
+	visited := make(map[int]bool)
+	for {
+		visited[port] = true
+		pos := current.ID
+		if pos == key {
+			return port,hops
+		}
+		refs := []NodeRef{}
+		for _,ref := range current.Routes {
+			if ref.Port != port {
+				refs = append(refs,ref)
+			}
+		}
+		sort.SliceStable(refs,func(a, b int) bool {
+			return ringdistance(pos,refs[a].ID) < ringdistance(pos,refs[b].ID)
+		})
+		//The successor is the closest node of the successor link, the successor list and the fingers that answers.
+		successor, closest := -1, ringsize
+		for _,ref := range refs {
+			if routes(ref.Port) != nil {
+				successor, closest = ref.Port, ringdistance(pos,ref.ID)
+				break
+			}
+		}
+		if successor < 0 {
+			return port,hops
+		}
+		if ringdistance(pos,key) <= closest {
+			return successor,hops
+		}
+		next := -1
+		for i := len(refs)-1; i >= 0; i-- {
+			if ringdistance(pos,refs[i].ID) < ringdistance(pos,key) && routes(refs[i].Port) != nil {
+				next = refs[i].Port
+				break
+			}
+		}
+		if next < 0 {
+			next = successor
+		}
+		if visited[next] {
+			return successor,hops
+		}
+		hops++
+		port, current = next, routes(next)
+	}
+}
+
+/*This function is used to return the nodes of a finger table in the order of the finger index.
+*input: The finger table.
+*output: The port nos of the fingers.*/
+func fingervalues(fingertable map[int]int) []int {
+	starts := []int{}
+	for start := range fingertable {
+		starts = append(starts,start)
+	}
+	sort.Ints(starts)
+	values := []int{}
+	for _,start := range starts {
+		values = append(values,fingertable[start])
+	}
+	return values
+}
+
+/*This function is used to return the distance from a position of the ring to another, going clockwise.
+*input: The two positions.
+*output: The distance.*/
+func ringdistance(from int, to int) int {
+	return ((to-from)%ringsize+ringsize)%ringsize
+}
+
+/*This function refers to converting the data to its corresponding hash value.
+*input: The input refers to the key and relationship of the data.
+*output: The output refers to the hash value that is generated from the given input.*/
+func DataHash(key,rel string) int{
+	nonce := []byte("875")
+  sumkey := 0
+  keybytes := []byte(key)
+  relbytes := []byte(rel)
+  for i:= 0; i < len(keybytes);i++ {
+    sumkey += int(keybytes[i]) * int(nonce[(i%3)])
+  }
+  sumkey %= 128
+  sumrel := 0
+  for j := 0; j < len(relbytes); j++ {
+    sumrel += int(relbytes[j]) * int(nonce[(j%3)])
+  }
+  sumrel %= 128
+	hash := int((sumkey >> (8-4) << 4) + int(sumrel & 0x0f))
+	return hash
+}
+
+/*The contains function that is used to stabilize a ring when a node enters or leaves the chord ring.
+*The successor and predecessor for each of the chord ring nodes are calculated after they have been
+fitted into the chord ring. The finger table for each of the nodes is also calculated. When the ring has split
+into separate rings every ring is stabilized on its own, and every node remembers the nodes of its ring.*/
+func StabilizeRing() bool {
+	for _,view := range ringviews() {
+		now := time.Now()
+		for key,val := range view {
+			//To find the successor for each of the element in the ring
+			temp := servermap[val]
+			pos := key
+			for true {
+				if value,ok := view[(pos+1)%128];ok{
+					if temp.successor != value {
+						observelinks(val,1,0)
+					}
+					temp.successor = value
+					break
+				} else {
+					pos++
+					continue
+				}
+			}
+
+			//To find the predecessor of the element in the ring
+			temp.predecessor = viewpredecessor(view,key)
+
+			//To find the nodes that follow the element in the ring
+			temp.successors = nil
+			for next := temp.successor; next != val && len(temp.successors) < successorlist; next = viewsuccessor(view,(ringposition(next)+1)%ringsize) {
+				temp.successors = append(temp.successors,next)
+			}
+
+			//To fill finger table for each of the ring elements
+			for i:=0;i<7;i++ {
+				pos = (key + int(math.Pow(2,float64(i))))%128
+				fingertable_key := pos
+				for true {
+					if value,ok := view[(pos)%128];ok {
+						if temp.fingertable[fingertable_key] != value {
+							observelinks(val,0,1)
+						}
+						temp.fingertable[fingertable_key] = value
+						break
+					} else {
+						pos++
+						continue
+					}
+				}
+			}
+			for _,member := range view {
+				if member != val {
+					temp.members[member] = now
+				}
+			}
+			temp.stabilized = now
+			servermap[val] = temp
+		}
+	}
+	return true
+}
+
+/*This function is used to return the positions of the nodes of every ring, which is a single ring unless the ring has split.
+*output: The positions of the nodes of every ring by the id of the ring.*/
+func ringviews() map[int]map[int]int {
+	views := make(map[int]map[int]int)
+	for pos,port := range ringmap {
+		id := ringids[port]
+		if views[id] == nil {
+			views[id] = make(map[int]int)
+		}
+		views[id][pos] = port
+	}
+	return views
+}
+
+/*This function is used to find the owner of a hash in the ring of a node, the successor of the hash among the nodes of that ring.
+*input: The hash and the port no of the node.
+*output: The port no of the owner or -1 if the node is not in a ring.*/
+func ringowner(hash int, portno int) int {
+	return viewsuccessor(ringviews()[ringids[portno]],hash)
+}
+
+/*This contains the function that is used to create the hash for the input nodet to be inserted into the chord ring.
+*input: The only input is the port no of the starting server.
+*output: The computed hash of the incoming port.*/
+func createhash(portno string) int {
+  nonce := []byte("8757")
+  inputport := []byte(portno)
+  hash := 0
+  for i := 0;i < len(inputport);i++ {
+    hash += int(inputport[i]) * int(nonce[i])
+  }
+  hash %= ringsize
+  return hash
+}
+
+/*This function is used to find the position of a server in the Chord ring.
+*input: The port no of the server.
+*output: The position of the server in the ring or -1 if the server is not part of the ring.*/
+func ringposition(portno int) int {
+	for pos,port := range ringmap {
+		if port == portno {
+			return pos
+		}
+	}
+	return -1
+}
+
+/*This function is used to return the finger table entries of a server in the order of the finger index.
+*input: The position of the server in the ring and its finger table.
+*output: The finger table entries sorted by their distance from the server.*/
+func sortedfingers(pos int, fingertable map[int]int) []FingerEntry {
+	fingers := []FingerEntry{}
+	for start,node := range fingertable {
+		fingers = append(fingers,FingerEntry{start,node})
+	}
+	sort.Slice(fingers, func(i, j int) bool {
+		return (fingers[i].Start-pos+ringsize)%ringsize < (fingers[j].Start-pos+ringsize)%ringsize
+	})
+	return fingers
+}
+
+/*This function is used to capture the topology of the whole Chord ring.
+*output: The nodes of the ring with their links, finger tables and key counts sorted by their position.*/
+func BuildTopology() RingTopology {
+	topology := RingTopology{RingSize: ringsize, Nodes: []TopologyNode{}}
+	for pos,port := range ringmap {
+		server := servermap[port]
+		topology.Nodes = append(topology.Nodes,TopologyNode{pos,port,server.successor,server.predecessor,sortedfingers(pos,server.fingertable),len(server.data)})
+	}
+	sort.Slice(topology.Nodes, func(i, j int) bool { return topology.Nodes[i].ID < topology.Nodes[j].ID })
+	return topology
+}
+
+/*This function is used to render the topology of the Chord ring in the Graphviz DOT language.
+*Successor links are drawn as solid edges, predecessor links as dashed edges and finger entries as
+*dotted edges labelled with the start positions that point to the same node.
+*input: The topology of the ring.
+*output: The DOT description of the ring.*/
+func TopologyDOT(topology RingTopology) string {
+	var b strings.Builder
+	b.WriteString("digraph chord {\n")
+	b.WriteString("\tlayout=circo;\n")
+	b.WriteString("\tnode [shape=circle];\n")
+	for _,n := range topology.Nodes {
+		fmt.Fprintf(&b,"\t%d [label=\"%d\\n:%d\\nkeys=%d\"];\n",n.Port,n.ID,n.Port,n.Keys)
+	}
+	for _,n := range topology.Nodes {
+		fmt.Fprintf(&b,"\t%d -> %d [label=\"succ\"];\n",n.Port,n.Successor)
+		fmt.Fprintf(&b,"\t%d -> %d [label=\"pred\", style=dashed];\n",n.Port,n.Predecessor)
+		starts := make(map[int][]string)
+		order := []int{}
+		for _,f := range n.Fingers {
+			if _,ok := starts[f.Node]; !ok {
+				order = append(order,f.Node)
+			}
+			starts[f.Node] = append(starts[f.Node],strconv.Itoa(f.Start))
+		}
+		for _,node := range order {
+			fmt.Fprintf(&b,"\t%d -> %d [label=\"%s\", style=dotted, color=gray];\n",n.Port,node,strings.Join(starts[node],","))
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+/*This function is used to find the node that succeeds the given position in the Chord ring.
+*input: The position in the ring.
+*output: The port no of the first node at or after the position.*/
+func ringsuccessor(pos int) int {
+	return viewsuccessor(ringmap,pos)
+}
+
+/*This function is used to find the first node at or after the given position among the given nodes.
+*input: The positions of the nodes and the position.
+*output: The port no of the node or -1 if there are no nodes.*/
+func viewsuccessor(view map[int]int, pos int) int {
+	for i := 0; i < ringsize; i++ {
+		if port,ok := view[(pos+i)%ringsize]; ok {
+			return port
+		}
+	}
+	return -1
+}
+
+/*This function is used to check the consistency of the Chord ring.
+*input: The port no of the server to start the walk from and whether the misplaced triplets are to be moved to their owner,
+*which keeps the newer of its own copy and the misplaced one.
+*output: The report of all the problems found in the ring.*/
+func VerifyRing(portno int, repair bool) VerifyReport {
+	report := VerifyReport{BrokenLinks: []RingIssue{}, IncorrectFingers: []RingIssue{}, MisplacedKeys: []KeyIssue{}, UnderReplicated: []KeyIssue{}}
+
+	//Walking the ring along the successor links until the walk returns to the starting node.
+	visited := make(map[int]bool)
+	port := portno
+	for !visited[port] {
+		visited[port] = true
+		report.Nodes++
+		server := servermap[port]
+		pos := ringposition(port)
+		if pos < 0 {
+			report.BrokenLinks = append(report.BrokenLinks,RingIssue{port,"node is not placed in the ring"})
+			break
+		}
+		if expected := ringsuccessor((pos+1)%ringsize); server.successor != expected {
+			report.BrokenLinks = append(report.BrokenLinks,RingIssue{port,fmt.Sprintf("successor is %d, expected %d",server.successor,expected)})
+		}
+		if expected := ringpredecessor(pos); server.predecessor != expected {
+			report.BrokenLinks = append(report.BrokenLinks,RingIssue{port,fmt.Sprintf("predecessor is %d, expected %d",server.predecessor,expected)})
+		}
+		if succ,ok := servermap[server.successor]; !ok {
+			report.BrokenLinks = append(report.BrokenLinks,RingIssue{port,fmt.Sprintf("successor %d is not running",server.successor)})
+			break
+		} else if succ.predecessor != port {
+			report.BrokenLinks = append(report.BrokenLinks,RingIssue{port,fmt.Sprintf("successor %d has predecessor %d",server.successor,succ.predecessor)})
+		}
+		for i := 0; i < 7; i++ {
+			start := (pos + int(math.Pow(2,float64(i))))%ringsize
+			expected := ringsuccessor(start)
+			if node,ok := server.fingertable[start]; !ok {
+				report.IncorrectFingers = append(report.IncorrectFingers,RingIssue{port,fmt.Sprintf("finger %d is missing, expected %d",start,expected)})
+			} else if node != expected {
+				report.IncorrectFingers = append(report.IncorrectFingers,RingIssue{port,fmt.Sprintf("finger %d points to %d, expected %d",start,node,expected)})
+			}
+		}
+		port = server.successor
+	}
+	for port := range servermap {
+		if !visited[port] {
+			report.BrokenLinks = append(report.BrokenLinks,RingIssue{port,"node is not reachable along the successor links"})
+		}
+	}
+
+	//Checking that every triplet is stored on the successor of its hash.
+	for port,server := range servermap {
+		for k := range server.data {
+			owner := ringsuccessor(DataHash(k.key,k.relation))
+			if owner == port {
+				continue
+			}
+			report.MisplacedKeys = append(report.MisplacedKeys,KeyIssue{k.key,k.relation,port,owner})
+			if _,ok := servermap[owner].data[k]; !ok {
+				report.UnderReplicated = append(report.UnderReplicated,KeyIssue{k.key,k.relation,port,owner})
+			}
+		}
+	}
+	if repair {
+		for _,issue := range report.MisplacedKeys {
+			k := datakey{issue.Key,issue.Relationship}
+			v := servermap[issue.Node].data[k]
+			//The copy on the owner is only replaced when the misplaced copy is newer, and not when the owner has deleted it since.
+			stored, ok := servermap[issue.Owner].data[k]
+			t, dead := servermap[issue.Owner].tombstones[k]
+			if (!ok || newer(v,stored)) && !(dead && buried(t,v)) {
+				if err := storeEntry("move",issue.Owner,k,v); err != nil {
+					nodelog(issue.Node).Error("the triplet could not be moved to its owner","key",k.key,"relationship",k.relation,"owner",issue.Owner,"error",err)
+					continue
+				}
+			}
+			if err := dropEntry(issue.Node,k); err != nil {
+				nodelog(issue.Node).Error("the misplaced triplet could not be removed","key",k.key,"relationship",k.relation,"error",err)
+				continue
+			}
+			logChange("move",issue.Node,k,&v,nil)
+			report.Repaired++
+		}
+	}
+	report.Consistent = len(report.BrokenLinks) == 0 && len(report.IncorrectFingers) == 0 && len(report.MisplacedKeys) == 0
+	return report
+}
+
+/*This function is used to find the node that precedes the given position in the Chord ring.
+*input: The position in the ring.
+*output: The port no of the first node before the position.*/
+func ringpredecessor(pos int) int {
+	return viewpredecessor(ringmap,pos)
+}
+
+/*This function is used to find the first node before the given position among the given nodes.
+*input: The positions of the nodes and the position.
+*output: The port no of the node or -1 if there are no nodes.*/
+func viewpredecessor(view map[int]int, pos int) int {
+	for i := 1; i <= ringsize; i++ {
+		if port,ok := view[(pos-i+ringsize)%ringsize]; ok {
+			return port
+		}
+	}
+	return -1
+}
+
+/*This function is used to return a parameter of the input as a string.
+*input: The input JSON message and the index of the parameter.
+*output: The parameter, empty if it is missing or null.
+*error: The error if the parameter is not a string.*/
+func stringparam(input *JsonMessage, i int) (string, error) {
+	if i >= len(input.Params) || input.Params[i] == nil {
+		return "",nil
+	}
+	param, ok := input.Params[i].(string)
+	if !ok {
+		return "",errors.New("Params error - The param "+strconv.Itoa(i+1)+" has to be a string")
+	}
+	return param,nil
+}
+
+/*This function is used to return several parameters of the input as strings.
+*input: The input JSON message and the indexes of the parameters.
+*output: The parameters in the order of the indexes, empty if they are missing or null.
+*error: The error if a parameter is not a string.*/
+func stringparams(input *JsonMessage, indexes ...int) ([]string, error) {
+	params := make([]string,len(indexes))
+	for i,index := range indexes {
+		param, err := stringparam(input,index)
+		if err != nil {
+			return nil,err
+		}
+		params[i] = param
+	}
+	return params,nil
+}
+
+/*This function is used to check whether a request can use the override flag. Only an authenticated admin can, or every client
+*when the authentication is disabled.
+*input: The input JSON message.
+*output: Whether the read only and append only permissions are overridden.*/
+func overrides(input *JsonMessage) bool {
+	return input.Override && admin(input)
+}
+
+/*This function is used to check whether a request is made by an authenticated admin. Every client is taken to be an admin
+*when the authentication is disabled.
+*input: The input JSON message.
+*output: Whether the client is an admin.*/
+func admin(input *JsonMessage) bool {
+	if serverconfig.Auth.Disabled {
+		return true
+	}
+	for _,user := range serverconfig.Auth.Users {
+		if user.User == input.User {
+			return user.Role == "admin"
+		}
+	}
+	return false
+}
+
+/*This function is used to return a numeric parameter of the input as an integer.
+*input: The input JSON message and the index of the parameter.
+*output: The parameter and whether it is present and a whole number.*/
+func intparam(input *JsonMessage, i int) (int64, bool) {
+	if i >= len(input.Params) {
+		return 0,false
+	}
+	param, ok := input.Params[i].(float64)
+	if !ok || param != math.Trunc(param) {
+		return 0,false
+	}
+	return int64(param),true
+}
+
+/*This function is used to validate the permission given for a triplet. The permission is not case sensitive and
+*defaults to read-write when it is missing.
+*input: The permission given in the input.
+*output: The permission mode and the error if the permission is not R, RW or A.*/
+func parsepermission(permission string) (string, error) {
+	switch p := strings.ToUpper(strings.TrimSpace(permission)); p {
+	case "":
+		return permissionreadwrite,nil
+	case permissionread, permissionreadwrite, permissionappend:
+		return p,nil
+	}
+	return "",errors.New("Permission Error - Invalid permission "+strconv.Quote(permission)+", the permission must be R (read only), RW (read-write) or A (append only).")
+}
+
+/*This function is used to return the permission mode of a stored triplet. Triplets stored with a permission that
+*is not valid, e.g. by an older server, are treated as read only until an administrator changes it.
+*input: The value of the triplet.
+*output: The permission mode.*/
+func permissionof(v datavalue) string {
+	p, err := parsepermission(v.permission)
+	if err != nil || len(strings.TrimSpace(v.permission)) == 0 {
+		return permissionread
+	}
+	return p
+}
+
+/*This function is used to return the name of a permission mode that is used in the error messages.
+*input: The permission mode.
+*output: The name of the mode.*/
+func permissionname(permission string) string {
+	switch permission {
+	case permissionreadwrite:
+		return "Read-Write"
+	case permissionappend:
+		return "Append only"
+	}
+	return "Read only"
+}
+
+/*This function is used to check whether a triplet may be updated with a new value and permission. A read-write triplet
+*can always be updated, an append only triplet only with a value that starts with its current value and a read only
+*triplet not at all. Changing the permission of a triplet that is not read-write and any other violation is allowed
+*with the override flag.
+*input: The stored value, the new value and permission and the override flag.
+*output: The error describing the violation, if any.*/
+func checkupdate(v datavalue, value string, permission string, override bool) error {
+	if override {
+		return nil
+	}
+	current := permissionof(v)
+	switch {
+	case current == permissionread:
+		return errors.New("Permission Error - This value is Read only and cannot be updated. Use override to update it.")
+	case current == permissionappend && !strings.HasPrefix(value,v.content):
+		return errors.New("Permission Error - This value is Append only, the new value must start with the current value.")
+	case current != permissionreadwrite && permission != current:
+		return errors.New("Permission Error - This value is "+permissionname(current)+" and its permission cannot be changed. Use override to change it.")
+	}
+	return nil
+}
+
+/*This function is used to format a timestamp of a triplet in RFC3339. The timestamps are kept in UTC so that
+*they do not depend on the time zone of the server.
+*input: The timestamp.
+*output: The formatted timestamp, empty for the zero time.*/
+func formattimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+/*This function is used to parse a timestamp of a triplet. Both RFC3339 and the older "01/02/2006, 15:04:05" format are accepted.
+*The older format was written in the local time of the server, so it is read in the local time zone.
+*input: The formatted timestamp.
+*output: The time in UTC and the error if the timestamp is not valid.*/
+func parsetimestamp(t string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339Nano,t); err == nil {
+		return parsed.UTC(),nil
+	}
+	parsed, err := time.ParseInLocation(timestampformat,t,time.Local)
+	return parsed.UTC(),err
+}
+
+/*This function is used to parse the size of a triplet given in bytes or in the older "NKB" format.
+*input: The formatted size.
+*output: The size in bytes and the error if the size is not valid.*/
+func parsesize(size string) (int64, error) {
+	if strings.HasSuffix(size,"KB") {
+		kb, err := strconv.ParseInt(strings.TrimSuffix(size,"KB"),10,64)
+		return kb*1000,err
+	}
+	return strconv.ParseInt(size,10,64)
+}
+
+/*This function is used to return the time to live of new triplets that is configured by the deletetimeout.
+*output: The default time to live, zero when triplets are kept forever.*/
+func defaultttl() time.Duration {
+	if timediff > 0 {
+		return timediff
+	}
+	return 0
+}
+
+/*This function is used to find the time to live of a triplet that is being inserted.
+*input: The input JSON message holding the optional ttl in seconds.
+*output: The time to live and the error if the ttl is not valid.*/
+func entryttl(input *JsonMessage) (time.Duration, error) {
+	if input.TTL == nil {
+		return defaultttl(),nil
+	}
+	if *input.TTL < 0 {
+		return 0,errors.New("TTL error - The ttl cannot be negative")
+	}
+	return time.Duration(*input.TTL)*time.Second,nil
+}
+
+/*This function is used to check whether a triplet has expired. Expired triplets are treated as absent.
+*input: The value of the triplet and the current time.
+*output: The boolean which indicates whether the time to live has passed since the last access.*/
+func expired(v datavalue, now time.Time) bool {
+	if v.ttl <= 0 {
+		return false
+	}
+	return now.Sub(v.accessed) >= v.ttl
+}
+
+/*This function is used to delete all the expired triplets of a node.
+*input: The port no of the node and the current time.
+*output: The number of deleted triplets and the error if any.*/
+func expireEntries(portno int, now time.Time) (int, error) {
+	stale := []datakey{}
+	for k,v := range servermap[portno].data {
+		if expired(v,now) {
+			stale = append(stale,k)
+		}
+	}
+	for _,k := range stale {
+		v := servermap[portno].data[k]
+		if err := deleteEntry(portno,k); err != nil {
+			return 0,err
+		}
+		recordChange("expire",portno,k,&v,nil)
+	}
+	return len(stale),nil
+}
+
+/*This function runs in the background for every node and deletes its expired triplets once every sweep interval.
+*It stops once the node has left the ring.
+*input: The port no of the node.*/
+func sweeper(portno int) {
+	ticker := time.NewTicker(time.Duration(serverconfig.SweepInterval)*time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		ringlock.Lock()
+		if _,ok := servermap[portno]; !ok || !close[portno] {
+			ringlock.Unlock()
+			return
+		}
+		if _,err := expireEntries(portno,time.Now()); err != nil {
+			nodelog(portno).Error("the expired triplets could not be deleted","error",err)
+		}
+		ringlock.Unlock()
+	}
+}
+
+/*This function is used to convert a triplet to the structure that is persisted on the disk.
+*input: The key and the value of the triplet.
+*output: The record holding the triplet and its metadata.*/
+func torecord(k datakey, v datavalue) DICT3record {
+	ttl := int64(v.ttl/time.Second)
+	return DICT3record{k.key,k.relation,v.content,strconv.FormatInt(v.size,10),formattimestamp(v.created),formattimestamp(v.modified),formattimestamp(v.accessed),v.permission,&ttl,v.version,v.creator,""}
+}
+
+/*This function is used to convert the tombstone of a triplet to the structure that is persisted on the disk.
+*input: The key of the triplet and its tombstone.
+*output: The record holding the tombstone.*/
+func tombstonerecord(k datakey, t tombstone) DICT3record {
+	return DICT3record{Key: k.key, Relationship: k.relation, Version: t.version, Deleted: formattimestamp(t.deleted)}
+}
+
+/*This function is used to convert a persisted record of a tombstone back to the tombstone.
+*input: The record holding the tombstone.
+*output: The key of the triplet and its tombstone.*/
+func fromtombstone(r DICT3record) (datakey, tombstone) {
+	deleted,_ := parsetimestamp(r.Deleted)
+	return datakey{r.Key,r.Relationship},tombstone{r.Version,deleted}
+}
+
+/*This function is used to convert a persisted record back to a triplet. The size is taken from the value itself,
+*timestamps that cannot be parsed are left as the zero time.
+*input: The record holding the triplet and its metadata.
+*output: The key and the value of the triplet.*/
+func fromrecord(r DICT3record) (datakey, datavalue) {
+	ttl := defaultttl()
+	if r.TTL != nil {
+		ttl = time.Duration(*r.TTL)*time.Second
+	}
+	version := r.Version
+	if version <= 0 {
+		version = 1
+	}
+	created,_ := parsetimestamp(r.Created)
+	modified,_ := parsetimestamp(r.Modified)
+	accessed,_ := parsetimestamp(r.Accessed)
+	return datakey{r.Key,r.Relationship},datavalue{r.Value,int64(len(r.Value)),created,modified,accessed,r.Permission,ttl,version,r.Creator}
+}
+
+/*This function is used to open the durable storage of a node and to recover its data after a restart or a crash.
+*The snapshot of the node is loaded first and the write-ahead log is replayed on top of it. A torn write at the
+*end of the log is cut off so that new entries are appended after the last complete one.
+*input: The port no of the node.
+*output: The storage of the node, the recovered data and tombstones and the error if any.*/
+func openstore(portno int) (*nodestore, map[datakey]datavalue, map[datakey]tombstone, error) {
+	dir := filepath.Join(serverconfig.Storage.Dir,"node-"+strconv.Itoa(portno))
+	if err := os.MkdirAll(dir,0770); err != nil {
+		return nil,nil,nil,err
+	}
+	data := make(map[datakey]datavalue)
+	tombstones := make(map[datakey]tombstone)
+	if snapshot, err := os.Open(filepath.Join(dir,"snapshot.json")); err == nil {
+		scanner := bufio.NewScanner(snapshot)
+		scanner.Buffer(make([]byte,64*1024),64*1024*1024)
+		for scanner.Scan() {
+			var r DICT3record
+			if err := json.Unmarshal(scanner.Bytes(),&r); err != nil {
+				snapshot.Close()
+				return nil,nil,nil,errors.New("Storage error - The snapshot of the server "+strconv.Itoa(portno)+" is corrupted: "+err.Error())
+			}
+			if len(r.Deleted) != 0 {
+				k,t := fromtombstone(r)
+				tombstones[k] = t
+				continue
+			}
+			k,v := fromrecord(r)
+			data[k] = v
+		}
+		snapshot.Close()
+		if err := scanner.Err(); err != nil {
+			return nil,nil,nil,err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil,nil,nil,err
+	}
+
+	wal, err := os.OpenFile(filepath.Join(dir,"wal.log"),os.O_RDWR|os.O_CREATE,0660)
+	if err != nil {
+		return nil,nil,nil,err
+	}
+	reader := bufio.NewReader(wal)
+	var good int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		var entry walentry
+		if json.Unmarshal(line,&entry) != nil {
+			break
+		}
+		k,v := fromrecord(entry.Record)
+		switch {
+		case entry.Op == "delete" && len(entry.Record.Deleted) != 0:
+			delete(data,k)
+			_,tombstones[k] = fromtombstone(entry.Record)
+		case entry.Op == "delete" || entry.Op == "drop":
+			delete(data,k)
+			delete(tombstones,k)
+		default:
+			data[k] = v
+			delete(tombstones,k)
+		}
+		good += int64(len(line))
+	}
+	if info, err := wal.Stat(); err == nil && info.Size() > good {
+		logger.Warn("discarding incomplete entries at the end of the write-ahead log","file",wal.Name(),"bytes",info.Size()-good)
+		if err := wal.Truncate(good); err != nil {
+			wal.Close()
+			return nil,nil,nil,err
+		}
+	}
+	if _,err := wal.Seek(good,0); err != nil {
+		wal.Close()
+		return nil,nil,nil,err
+	}
+	return &nodestore{dir,wal},data,tombstones,nil
+}
+
+/*This function is used to append an entry to the write-ahead log of a node.
+*input: The operation and the record to log.
+*output: The error if the entry could not be written.*/
+func (store *nodestore) append(op string, r DICT3record) error {
+	line, err := json.Marshal(walentry{op,r})
+	if err != nil {
+		return err
+	}
+	if _,err := store.wal.Write(append(line,'\n')); err != nil {
+		return err
+	}
+	if serverconfig.Storage.Fsync == "always" {
+		return store.wal.Sync()
+	}
+	return nil
+}
+
+/*This function is used to write a snapshot of the data and the tombstones of a node and to truncate its write-ahead log.
+*The snapshot is written to a temporary file first and renamed once it is complete.
+*input: The node.
+*output: The error if the snapshot could not be written.*/
+func (store *nodestore) snapshot(server Server) error {
+	if err := store.stage(server); err != nil {
+		return err
+	}
+	return store.install()
+}
+
+/*This function is used to write the snapshot of a node to its temporary file without replacing the snapshot of the node yet.
+*input: The node.
+*output: The error if the snapshot could not be written, in which case the temporary file is removed.*/
+func (store *nodestore) stage(server Server) error {
+	tmpname := filepath.Join(store.dir,"snapshot.tmp")
+	tmp, err := os.Create(tmpname)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(tmpname)
+		}
+	}()
+	w := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(w)
+	for k,v := range server.data {
+		if err = encoder.Encode(torecord(k,v)); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	for k,t := range server.tombstones {
+		if err = encoder.Encode(tombstonerecord(k,t)); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	return tmp.Close()
+}
+
+/*This function is used to replace the snapshot of a node with the snapshot written by stage and to truncate its write-ahead log.
+*output: The error if the snapshot could not be replaced.*/
+func (store *nodestore) install() error {
+	if err := os.Rename(filepath.Join(store.dir,"snapshot.tmp"),filepath.Join(store.dir,"snapshot.json")); err != nil {
+		return err
+	}
+	if err := store.wal.Truncate(0); err != nil {
+		return err
+	}
+	_,err := store.wal.Seek(0,0)
+	return err
+}
+
+/*This function is used to store a triplet on a node and to log it in the write-ahead log of the node.
+*input: The port no of the node and the triplet.
+*output: The error if the triplet could not be logged.*/
+func putEntry(portno int, k datakey, v datavalue) error {
+	if old, ok := servermap[portno].data[k]; ok {
+		countquota(portno,k,old,-1)
+	}
+	servermap[portno].data[k] = v
+	delete(servermap[portno].tombstones,k)
+	countquota(portno,k,v,1)
+	if store,ok := stores[portno]; ok {
+		if err := store.append("put",torecord(k,v)); err != nil {
+			return errors.New("Storage error - "+err.Error())
+		}
+	}
+	return nil
+}
+
+/*This function is used to set the access time of a triplet when it is read. The access time is only kept in memory and is
+*written to the durable storage with the next snapshot of the node, so that a read does not write to the write-ahead log.
+*input: The port no of the node and the triplet.*/
+func touchEntry(portno int, k datakey, v datavalue) {
+	v.accessed = time.Now().UTC()
+	servermap[portno].data[k] = v
+}
+
+/*This function is used to delete a triplet from a node and to log the deletion in the write-ahead log of the node. The triplet
+*leaves a tombstone with the next version, so that a triplet inserted with the same key later continues after it.
+*input: The port no of the node and the key of the triplet.
+*output: The error if the deletion could not be logged.*/
+func deleteEntry(portno int, k datakey) error {
+	old, ok := servermap[portno].data[k]
+	if !ok {
+		return nil
+	}
+	return putTombstone(portno,k,tombstone{old.version+1,time.Now().UTC()})
+}
+
+/*This function is used to store the tombstone of a triplet on a node in place of the triplet and to log it in the write-ahead
+*log of the node.
+*input: The port no of the node, the key of the triplet and its tombstone.
+*output: The error if the tombstone could not be logged.*/
+func putTombstone(portno int, k datakey, t tombstone) error {
+	if old, ok := servermap[portno].data[k]; ok {
+		countquota(portno,k,old,-1)
+	}
+	delete(servermap[portno].data,k)
+	servermap[portno].tombstones[k] = t
+	if store,ok := stores[portno]; ok {
+		if err := store.append("delete",tombstonerecord(k,t)); err != nil {
+			return errors.New("Storage error - "+err.Error())
+		}
+	}
+	return nil
+}
+
+/*This function is used to remove a triplet or its tombstone from a node that has handed it to its owner, without leaving a
+*tombstone, and to log the removal in the write-ahead log of the node.
+*input: The port no of the node and the key of the triplet.
+*output: The error if the removal could not be logged.*/
+func dropEntry(portno int, k datakey) error {
+	if old, ok := servermap[portno].data[k]; ok {
+		countquota(portno,k,old,-1)
+	}
+	delete(servermap[portno].data,k)
+	delete(servermap[portno].tombstones,k)
+	if store,ok := stores[portno]; ok {
+		if err := store.append("drop",DICT3record{Key: k.key, Relationship: k.relation}); err != nil {
+			return errors.New("Storage error - "+err.Error())
+		}
+	}
+	return nil
+}
+
+/*This function is used to store a triplet that is written by an import, a restore or a load of the DICT3 file, or that is
+*handed to the node, and to record the change. A triplet that is stored again with the same version is not recorded.
+*input: The change, "insert" or "update" when it is empty, the port no of the node, the key of the triplet and its value.
+*output: The error if any.*/
+func storeEntry(op string, portno int, k datakey, v datavalue) error {
+	old, existed := servermap[portno].data[k]
+	if err := putEntry(portno,k,v); err != nil {
+		return err
+	}
+	switch {
+	case existed && old.version == v.version && old.content == v.content:
+	case existed && len(op) == 0:
+		recordChange("update",portno,k,&old,&v)
+	case existed:
+		recordChange(op,portno,k,&old,&v)
+	case len(op) == 0:
+		recordChange("insert",portno,k,nil,&v)
+	default:
+		recordChange(op,portno,k,nil,&v)
+	}
+	return nil
+}
+
+/*This function is used to return the version of a triplet that is inserted on a node, which follows the version of the expired
+*triplet or of the tombstone the node holds for its key.
+*input: The port no of the node and the key of the triplet.
+*output: The version of the new triplet.*/
+func nextversion(portno int, k datakey) int64 {
+	var last int64
+	if v, ok := servermap[portno].data[k]; ok {
+		last = v.version
+	}
+	if t, ok := servermap[portno].tombstones[k]; ok && t.version > last {
+		last = t.version
+	}
+	return last+1
+}
+
+/*This function is used to remove the durable storage and the change log of a node that has left the ring after handing its data to its successor.
+*input: The port no of the node.*/
+func removestore(portno int) {
+	if changes,ok := changelogs[portno]; ok {
+		changes.file.Close()
+		delete(changelogs,portno)
+	}
+	if store,ok := stores[portno]; ok {
+		store.wal.Close()
+		if err := os.RemoveAll(store.dir); err != nil {
+			nodelog(portno).Error("the storage could not be removed","dir",store.dir,"error",err)
+		}
+		delete(stores,portno)
+	}
+}
+
+/*This function is used to read the operations of a transaction from the input and to check that they are well formed.
+*input: The input JSON message holding the list of operations as its first param.
+*output: The operations and the error if the list is not valid.*/
+func txops(input *JsonMessage) ([]TxOp, error) {
+	if len(input.Params) < 1 {
+		return nil,errors.New("Transaction error - The list of operations is required")
+	}
+	raw, err := json.Marshal(input.Params[0])
+	if err != nil {
+		return nil,errors.New("Transaction error - "+err.Error())
+	}
+	var ops []TxOp
+	if err := json.Unmarshal(raw,&ops); err != nil {
+		return nil,errors.New("Transaction error - The operations must be a list of objects: "+err.Error())
+	}
+	if len(ops) == 0 {
+		return nil,errors.New("Transaction error - The list of operations is empty")
+	}
+	seen := make(map[datakey]int)
+	for i := range ops {
+		ops[i].Op = strings.ToLower(strings.TrimSpace(ops[i].Op))
+		ops[i].Key = strings.TrimSpace(ops[i].Key)
+		ops[i].Relationship = strings.TrimSpace(ops[i].Relationship)
+		if ops[i].Op != "put" && ops[i].Op != "delete" {
+			return nil,errors.New("Transaction error - Operation "+strconv.Itoa(i+1)+" must be put or delete")
+		}
+		if len(ops[i].Key) == 0 || len(ops[i].Relationship) == 0 {
+			return nil,errors.New("Transaction error - Operation "+strconv.Itoa(i+1)+": Key or Relationship attributes cannot be null")
+		}
+		k := datakey{ops[i].Key,ops[i].Relationship}
+		if j,ok := seen[k]; ok {
+			return nil,errors.New("Transaction error - Operations "+strconv.Itoa(j+1)+" and "+strconv.Itoa(i+1)+" change the same triplet")
+		}
+		seen[k] = i
+	}
+	return ops,nil
+}
+
+/*This function is used to check that a triplet is not locked by a transaction that has not been completed.
+*input: The port no of the node and the key of the triplet.
+*output: The error if the triplet is locked.*/
+func checklock(portno int, k datakey) error {
+	if holder, locked := servermap[portno].locks[k]; locked {
+		return errors.New("Transaction error - The triplet ("+k.key+", "+k.relation+") is locked by the transaction "+holder)
+	}
+	return nil
+}
+
+/*This function is used by a participant of a transaction to prepare its operations. The conditions of every operation are
+*checked against the data of the node, the triplets are locked for the transaction and the resulting changes are kept until
+*the transaction is committed or aborted. Nothing is changed if any operation cannot be applied.
+*input: The port no of the node, the id of the transaction, the operations, the indices of the operations owned by the node, the override flag
+*and the client that creates the new triplets.
+*output: The prepared changes in the order of the indices and the error if the node votes to abort.*/
+func prepare(portno int, id string, ops []TxOp, indices []int, override bool, client string) ([]walentry, error) {
+	server := servermap[portno]
+	now := time.Now().UTC()
+	staged := []walentry{}
+	for _,i := range indices {
+		op := ops[i]
+		k := datakey{op.Key,op.Relationship}
+		if holder, locked := server.locks[k]; locked && holder != id {
+			return nil,errors.New("Transaction error - The triplet ("+op.Key+", "+op.Relationship+") is locked by the transaction "+holder)
+		}
+		oldvalue, ok := server.data[k]
+		if ok && expired(oldvalue,now) {
+			ok = false
+		}
+		var current int64
+		if ok {
+			current = oldvalue.version
+		}
+		if op.ExpectedVersion != nil && *op.ExpectedVersion != current {
+			return nil,errors.New("Conflict error - Operation "+strconv.Itoa(i+1)+": The stored version of the triplet is "+strconv.FormatInt(current,10)+", expected "+strconv.FormatInt(*op.ExpectedVersion,10))
+		}
+		if op.Op == "delete" {
+			if !ok {
+				return nil,errors.New("Transaction error - Operation "+strconv.Itoa(i+1)+": Key and/or Relationship not found in DICT3")
+			}
+			if permissionof(oldvalue) != permissionreadwrite && !override {
+				return nil,errors.New("Permission Error - Operation "+strconv.Itoa(i+1)+": This value is "+permissionname(permissionof(oldvalue))+" and cannot be deleted. Use override to delete it.")
+			}
+			staged = append(staged,walentry{"delete",DICT3record{Key: op.Key, Relationship: op.Relationship}})
+			continue
+		}
+		ttl := defaultttl()
+		if op.TTL != nil {
+			if *op.TTL < 0 {
+				return nil,errors.New("TTL error - Operation "+strconv.Itoa(i+1)+": The ttl cannot be negative")
+			}
+			ttl = time.Duration(*op.TTL)*time.Second
+		} else if ok {
+			ttl = oldvalue.ttl
+		}
+		permission, err := parsepermission(op.Permission)
+		if err != nil {
+			return nil,err
+		}
+		v := datavalue{op.Value,int64(len(op.Value)),now,time.Time{},now,permission,ttl,nextversion(portno,k),client}
+		if ok {
+			if len(strings.TrimSpace(op.Permission)) == 0 {
+				permission = permissionof(oldvalue)
+			}
+			if err := checkupdate(oldvalue,op.Value,permission,override); err != nil {
+				return nil,err
+			}
+			v = datavalue{op.Value,int64(len(op.Value)),oldvalue.created,now,now,permission,ttl,oldvalue.version+1,oldvalue.creator}
+		}
+		staged = append(staged,walentry{"put",torecord(k,v)})
+	}
+	for _,w := range staged {
+		server.locks[datakey{w.Record.Key,w.Record.Relationship}] = id
+	}
+	server.prepared[id] = staged
+	return staged,nil
+}
+
+/*This function is used by a participant to apply the changes it has prepared for a committed transaction. The locks are kept until
+*every participant has committed, so that no other change is made to the triplets before the transaction is complete.
+*If the participant has left the ring since it prepared, the changes are redone on the nodes that own the triplets now.
+*input: The port no of the participant, the id of the transaction and the changes of the participant from the coordinator log.
+*output: The error if any.*/
+func commit(portno int, id string, writes []walentry) error {
+	server, ok := servermap[portno]
+	if !ok {
+		return redo(writes)
+	}
+	if _,prepared := server.prepared[id]; !prepared {
+		return redo(writes)
+	}
+	for _,w := range writes {
+		if err := applywrite(portno,w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*This function is used by a participant to drop the changes it has prepared for a transaction and to release its locks.
+*input: The port no of the participant and the id of the transaction.*/
+func abort(portno int, id string) {
+	server, ok := servermap[portno]
+	if !ok {
+		return
+	}
+	for k,holder := range server.locks {
+		if holder == id {
+			delete(server.locks,k)
+		}
+	}
+	delete(server.prepared,id)
+}
+
+/*This function is used by the coordinator of a transaction to let the participants drop the transaction and release its locks.
+*A participant that cannot be reached keeps its locks until the transaction is completed or the node is restarted.
+*input: The port no of the coordinator, the id of the transaction and the port nos of the participants.*/
+func aborttransaction(from int, id string, ports []int) {
+	for _,port := range ports {
+		if err := ringcall(from,port,"Node.Abort",&NodeWrites{ID: id},&NoOutput{}); err != nil {
+			nodelog(port).Warn("the locks of the transaction could not be released","transaction",id,"error",err)
+		}
+	}
+}
+
+/*This function is used to apply the changes of a committed transaction on the nodes that currently own the triplets. The
+*changes hold the complete triplets, so applying them again after a partial commit gives the same result.
+*input: The changes of the transaction.
+*output: The error if any.*/
+func redo(writes []walentry) error {
+	for _,w := range writes {
+		if err := applywrite(ringsuccessor(DataHash(w.Record.Key,w.Record.Relationship)),w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*This function is used to apply a single change of a committed transaction on a node.
+*input: The port no of the node and the change.
+*output: The error if any.*/
+func applywrite(portno int, w walentry) error {
+	k,v := fromrecord(w.Record)
+	old, existed := servermap[portno].data[k]
+	if w.Op == "delete" {
+		if !existed {
+			return nil
+		}
+		if err := deleteEntry(portno,k); err != nil {
+			return err
+		}
+		recordChange("delete",portno,k,&old,nil)
+		return nil
+	}
+	//A change that is redone after it has already been applied is not reported again.
+	if existed && old.version == v.version {
+		return putEntry(portno,k,v)
+	}
+	if err := putEntry(portno,k,v); err != nil {
+		return err
+	}
+	if existed {
+		recordChange("update",portno,k,&old,&v)
+	} else {
+		recordChange("insert",portno,k,nil,&v)
+	}
+	return nil
+}
+
+/*This function is used to return the path of the coordinator log. As the transactions are executed one at a time, the log
+*only holds the transaction that is being committed and is removed once the commit is complete.
+*output: The path of the log.*/
+func txlogpath() string {
+	return filepath.Join(serverconfig.Storage.Dir,"transactions.log")
+}
+
+/*This function is used to durably write the commit decision of a transaction to the coordinator log. The log is never
+*overwritten, as it holds a transaction that has not been completed until it is removed.
+*input: The record of the transaction.
+*output: The error if any.*/
+func writetxlog(entry txlogentry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(serverconfig.Storage.Dir,0770); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(txlogpath(),os.O_WRONLY|os.O_CREATE|os.O_EXCL,0660)
+	if os.IsExist(err) {
+		return errors.New("the log still holds a transaction that has not been completed")
+	} else if err != nil {
+		return err
+	}
+	if _,err := file.Write(append(line,'\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+/*This function is used at startup and before every transaction to complete a transaction whose commit was interrupted by a
+*crash or by a participant that failed. A committed transaction is redone on the nodes that own its triplets now and its locks
+*are released, while a log that cannot be read was not completely written, so its transaction was never committed. The log is
+*kept when the transaction cannot be completed.
+*output: The id of the completed transaction, empty if there was none, and the error if any.*/
+func recovertransaction() (string, error) {
+	line, err := os.ReadFile(txlogpath())
+	if os.IsNotExist(err) {
+		return "",nil
+	} else if err != nil {
+		return "",err
+	}
+	var entry txlogentry
+	if json.Unmarshal(line,&entry) == nil && entry.State == "commit" {
+		if err := redo(entry.Writes); err != nil {
+			return entry.ID,err
+		}
+		for port := range servermap {
+			abort(port,entry.ID)
+		}
+	} else {
+		entry.ID = ""
+	}
+	return entry.ID,os.Remove(txlogpath())
+}
+
+/*This function is called for every change of a triplet, whether it is made by a client, by the expiry of the triplet or by
+*handing the triplet to another node. It appends the change to the change log of the node, queues it for every watch that
+*matches the triplet and wakes up the polls that are waiting for it.
+*input: The change, the port no of the node storing the triplet, the key of the triplet and its old and new value, either of
+*which is nil when the triplet is inserted or deleted.*/
+func recordChange(op string, portno int, k datakey, old *datavalue, value *datavalue) {
+	now := logChange(op,portno,k,old,value)
+	v := value
+	if v == nil {
+		v = old
+	}
+	if v == nil {
+		return
+	}
+	event := ChangeEvent{op,k.key,k.relation,v.content,v.version,portno,now}
+	watchlock.Lock()
+	defer watchlock.Unlock()
+	for _,w := range watches {
+		if (len(w.key) != 0 && w.key != k.key) || (len(w.relation) != 0 && w.relation != k.relation) {
+			continue
+		}
+		if len(w.events) >= maxwatchevents {
+			w.events = w.events[1:]
+			w.dropped++
+		}
+		w.events = append(w.events,event)
+		select {
+		case w.notify <- true:
+		default:
+		}
+	}
+}
+
+/*This function is used to append a change of a triplet to the change log of a node without telling the watches, as the node
+*that hands a triplet to another node does, where the watches are told by the node that receives it.
+*input: The change, the port no of the node, the key of the triplet and its old and new value, either of which can be nil.
+*output: The timestamp of the change.*/
+func logChange(op string, portno int, k datakey, old *datavalue, value *datavalue) string {
+	observechange(portno,op)
+	now := formattimestamp(time.Now())
+	if changes, ok := changelogs[portno]; ok && (old != nil || value != nil) {
+		r := ChangeRecord{Op: op, Key: k.key, Relationship: k.relation, Time: now}
+		if old != nil {
+			r.OldValue, r.OldVersion = &old.content, old.version
+		}
+		if value != nil {
+			r.NewValue, r.NewVersion = &value.content, value.version
+		}
+		if err := changes.append(r); err != nil {
+			nodelog(portno).Error("the change log could not be written","error",err)
+		}
+	}
+	return now
+}
+
+/*This function is used to open the change log of a node and to read the changes that it still keeps. A torn write at the end of
+*the log is cut off.
+*input: The port no of the node.
+*output: The change log and the error if any.*/
+func openchangelog(portno int) (*changelog, error) {
+	path := filepath.Join(serverconfig.Storage.Dir,"node-"+strconv.Itoa(portno),"changes.log")
+	file, err := os.OpenFile(path,os.O_RDWR|os.O_CREATE,0660)
+	if err != nil {
+		return nil,err
+	}
+	changes := &changelog{file,0,[]ChangeRecord{},0}
+	reader := bufio.NewReader(file)
+	var good int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		var r ChangeRecord
+		if json.Unmarshal(line,&r) != nil {
+			break
+		}
+		changes.entries = append(changes.entries,r)
+		changes.seq = r.Seq
+		changes.written++
+		good += int64(len(line))
+	}
+	if err := file.Truncate(good); err != nil {
+		file.Close()
+		return nil,err
+	}
+	if _,err := file.Seek(good,0); err != nil {
+		file.Close()
+		return nil,err
+	}
+	changes.trim(time.Now())
+	return changes,nil
+}
+
+/*This function is used to append a change to the change log of a node. The change is given the next sequence number and
+*is synced to the disk according to the fsync policy of the storage.
+*input: The change.
+*output: The error if any.*/
+func (changes *changelog) append(r ChangeRecord) error {
+	r.Seq = changes.seq+1
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _,err := changes.file.Write(append(line,'\n')); err != nil {
+		return err
+	}
+	if serverconfig.Storage.Fsync == "always" {
+		if err := changes.file.Sync(); err != nil {
+			return err
+		}
+	}
+	changes.seq = r.Seq
+	changes.entries = append(changes.entries,r)
+	changes.written++
+	changes.trim(time.Now())
+	if changes.written > 2*len(changes.entries) && changes.written > 1000 {
+		return changes.compact()
+	}
+	return nil
+}
+
+/*This function is used to drop the changes that are no longer kept by the retention of the change log, i.e. all but the last
+*"retention" changes and the changes older than "maxage" seconds. The last change is always kept so that the sequence numbers
+*continue after a restart.
+*input: The current time.*/
+func (changes *changelog) trim(now time.Time) {
+	drop := 0
+	if len(changes.entries) > serverconfig.CDC.Retention {
+		drop = len(changes.entries)-serverconfig.CDC.Retention
+	}
+	if serverconfig.CDC.MaxAge > 0 {
+		for drop < len(changes.entries)-1 {
+			t, err := parsetimestamp(changes.entries[drop].Time)
+			if err != nil || now.Sub(t) <= time.Duration(serverconfig.CDC.MaxAge)*time.Second {
+				break
+			}
+			drop++
+		}
+	}
+	if drop >= len(changes.entries) {
+		drop = len(changes.entries)-1
+	}
+	if drop > 0 {
+		changes.entries = append([]ChangeRecord{},changes.entries[drop:]...)
+	}
+}
+
+/*This function is used to rewrite the file of a change log with only the changes that are kept.
+*output: The error if any.*/
+func (changes *changelog) compact() error {
+	path := changes.file.Name()
+	tmp, err := os.Create(path+".tmp")
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(tmp)
+	for _,r := range changes.entries {
+		line, err := json.Marshal(r)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(append(line,'\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+	if err := os.Rename(path+".tmp",path); err != nil {
+		return err
+	}
+	changes.file.Close()
+	file, err := os.OpenFile(path,os.O_WRONLY|os.O_APPEND,0660)
+	if err != nil {
+		return err
+	}
+	changes.file = file
+	changes.written = len(changes.entries)
+	return nil
+}
+
+/*This function is used to create the codec of a client connection.
+*input: The connection.
+*output: The codec.*/
+func newNodeCodec(conn net.Conn, portno int) *nodeCodec {
+	return &nodeCodec{ServerCodec: jsonrpc.NewServerCodec(conn), port: portno, client: conn.RemoteAddr().String(), pending: make(map[uint64]*auditrequest)}
+}
+
+/*This function reads the header of a request and starts timing it for the metrics and the audit log. The body of the request
+*is always read right after its header.*/
+func (c *nodeCodec) ReadRequestHeader(r *rpc.Request) error {
+	if err := c.ServerCodec.ReadRequestHeader(r); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	c.seq = r.Seq
+	c.method = r.ServiceMethod
+	c.pending[r.Seq] = &auditrequest{r.ServiceMethod,nil,time.Now()}
+	c.lock.Unlock()
+	return nil
+}
+
+/*This function reads the input of a request, sets the address of the client, the id and the trace of the request in it, rejects it if the function is not exposed,
+*authenticates it and applies the rate limits of the client. A request that is
+*rejected is answered with the error and is not passed to the function.*/
+func (c *nodeCodec) ReadRequestBody(body interface{}) error {
+	if err := c.ServerCodec.ReadRequestBody(body); err != nil {
+		return err
+	}
+	if input, ok := body.(*JsonMessage); ok {
+		input.Client = c.client
+		input.RequestID = strconv.Itoa(c.port)+"-"+strconv.FormatUint(requestcount.Add(1),10)
+		c.lock.Lock()
+		if pending, ok := c.pending[c.seq]; ok {
+			pending.input = input
+			input.trace = starttrace(input,c.method,c.port,pending.start)
+		}
+		method := c.method
+		c.lock.Unlock()
+		if !exposed(method) {
+			return errors.New("rpc: can't find method "+method)
+		}
+		if err := authenticate(method,input); err != nil {
+			return err
+		}
+		return ratelimit(method,input)
+	}
+	return nil
+}
+
+/*This function writes the response of a request, exports its trace, logs it, counts the call in the metrics of the node and appends the call to
+*the audit log if the function changes the ring. Successful calls are logged at the debug level and failed calls as warnings.*/
+func (c *nodeCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	c.lock.Lock()
+	pending, ok := c.pending[r.Seq]
+	delete(c.pending,r.Seq)
+	c.lock.Unlock()
+	err := c.ServerCodec.WriteResponse(r,body)
+	if ok && pending.input != nil && pending.input.trace != nil {
+		exporttrace(pending.input.trace,r.Error)
+	}
+	if ok {
+		latency := time.Since(pending.start)
+		observerequest(c.port,pending.method,len(r.Error) == 0,latency)
+		log := nodelog(c.port).With("method",strings.TrimPrefix(pending.method,"Dict3."),"client",c.client,"latency_ms",float64(latency.Microseconds())/1000)
+		if pending.input != nil {
+			log = log.With("request",pending.input.RequestID)
+			if pending.input.trace != nil {
+				log = log.With("trace",pending.input.trace.root.TraceID)
+			}
+			if len(pending.input.User) != 0 {
+				log = log.With("user",pending.input.User)
+			}
+		}
+		if len(r.Error) != 0 {
+			log.Warn("request failed","error",r.Error)
+		} else {
+			log.Debug("request")
+		}
+	}
+	if ok && pending.input != nil && auditedmethods[pending.method] {
+		audit(pending,r.Error)
+	}
+	return err
+}
+
+/*This function is used to return the names of all the functions of the Dict3 service.
+*output: The names of the functions in alphabetical order.*/
+func dict3methods() []string {
+	t := reflect.TypeOf(new(Dict3))
+	methods := make([]string,0,t.NumMethod())
+	for i := 0; i < t.NumMethod(); i++ {
+		methods = append(methods,t.Method(i).Name)
+	}
+	return methods
+}
+
+/*This function is used to build the functions the server exposes from the methods of the config file. The names of the config
+*file are not case sensitive and the describe function is always exposed.
+*output: The error if the config file names a function that does not exist.*/
+func initmethods() error {
+	if len(serverconfig.Methods) == 0 {
+		return nil
+	}
+	exposedmethods = map[string]bool{"Dict3.Describe": true}
+	methods := dict3methods()
+	for _,name := range serverconfig.Methods {
+		found := false
+		for _,method := range methods {
+			if strings.EqualFold(name,method) {
+				exposedmethods["Dict3."+method] = true
+				found = true
+			}
+		}
+		if !found {
+			return errors.New("Config error - The method "+name+" of the config file does not exist at the server")
+		}
+	}
+	return nil
+}
+
+/*This function is used to check whether a function can be called at the server.
+*input: The name of the function, e.g. "Dict3.LookUp".
+*output: True if the function is exposed.*/
+func exposed(method string) bool {
+	return exposedmethods == nil || exposedmethods[method]
+}
+
+/*The upper bounds of the buckets of the latency histograms in seconds and of the hop histograms.*/
+var latencybuckets = []float64{0.0005,0.001,0.0025,0.005,0.01,0.025,0.05,0.1,0.25,0.5,1,2.5,5}
+var hopbuckets = []float64{0,1,2,3,4,5,6,7,8}
+
+/*This function is used to create a histogram.
+*input: The upper bounds of the buckets.
+*output: The histogram.*/
+func newhistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64,len(bounds))}
+}
+
+/*This function is used to add an observation to a histogram.
+*input: The observation.*/
+func (h *histogram) observe(value float64) {
+	for i,bound := range h.bounds {
+		if value <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += value
+	h.count++
+}
+
+/*This function is used to create the empty metrics of a node.
+*output: The metrics.*/
+func newnodemetrics() *nodemetrics {
+	return &nodemetrics{make(map[[2]string]uint64),make(map[string]*histogram),newhistogram(hopbuckets),make(map[string]uint64),0,0,make(map[string]int64)}
+}
+
+/*This function is used to count a call of a function in the metrics of the node the client is connected to.
+*input: The port no of the node, the function, whether the call succeeded and its latency.*/
+func observerequest(portno int, method string, ok bool, latency time.Duration) {
+	result := "ok"
+	if !ok {
+		result = "error"
+	}
+	method = strings.TrimPrefix(method,"Dict3.")
+	metricslock.Lock()
+	defer metricslock.Unlock()
+	m, ok := metrics[portno]
+	if !ok {
+		return
+	}
+	m.requests[[2]string{method,result}]++
+	if _,ok := m.durations[method]; !ok {
+		m.durations[method] = newhistogram(latencybuckets)
+	}
+	m.durations[method].observe(latency.Seconds())
+}
+
+/*This function is used to count the finger hops of a successor lookup.
+*input: The port no of the node the lookup started at and the number of hops.*/
+func observehops(portno int, hops int) {
+	metricslock.Lock()
+	if m, ok := metrics[portno]; ok {
+		m.hops.observe(float64(hops))
+	}
+	metricslock.Unlock()
+}
+
+/*This function is used to count the changes of the successor and the fingers of a node.
+*input: The port no of the node and the number of changes of the successor and the fingers.*/
+func observelinks(portno int, successor uint64, fingers uint64) {
+	metricslock.Lock()
+	if m, ok := metrics[portno]; ok {
+		m.successorchanges += successor
+		m.fingerchanges += fingers
+	}
+	metricslock.Unlock()
+}
+
+/*This function is used to count a change of a triplet of a node.
+*input: The port no of the node and the change.*/
+func observechange(portno int, op string) {
+	metricslock.Lock()
+	if m, ok := metrics[portno]; ok {
+		m.changes[op]++
+	}
+	metricslock.Unlock()
+}
+
+/*This function is used to remember the last sequence number of the change log of a node a consumer has read.
+*input: The port no of the node, the consumer and the sequence number.*/
+func observeconsumer(portno int, consumer string, seq int64) {
+	metricslock.Lock()
+	if m, ok := metrics[portno]; ok {
+		m.consumers[consumer] = seq
+	}
+	metricslock.Unlock()
+}
+
+/*This function serves the metrics of a node over HTTP, with the ping, health and ready endpoints that answer with the status
+*503 when the node is not healthy or not ready. It is started for every node and an error only turns them off for the node.
+*The metrics of the node are only counted from here on, and the server is closed by stopmetrics when the node leaves the ring.
+*input: The port no of the node.*/
+func startmetrics(portno int) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics",func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type","text/plain; version=0.0.4")
+		writemetrics(w,portno)
+	})
+	mux.HandleFunc("/ping",func(w http.ResponseWriter, r *http.Request) {
+		ringlock.Lock()
+		server, ok := servermap[portno]
+		position := ringposition(portno)
+		ringlock.Unlock()
+		if !ok {
+			writehealth(w,http.StatusServiceUnavailable,NodePing{portno,-1,0})
+			return
+		}
+		writehealth(w,http.StatusOK,NodePing{portno,position,time.Since(server.started).Seconds()})
+	})
+	mux.HandleFunc("/health",func(w http.ResponseWriter, r *http.Request) {
+		health := nodehealth(portno)
+		status := http.StatusOK
+		if !health.Healthy {
+			status = http.StatusServiceUnavailable
+		}
+		writehealth(w,status,health)
+	})
+	mux.HandleFunc("/ready",func(w http.ResponseWriter, r *http.Request) {
+		health := nodehealth(portno)
+		status := http.StatusOK
+		if !health.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writehealth(w,status,health)
+	})
+	server := &http.Server{Addr: ":"+strconv.Itoa(portno+serverconfig.Metrics.PortOffset), Handler: mux}
+	metricslock.Lock()
+	metrics[portno] = newnodemetrics()
+	metricservers[portno] = server
+	metricslock.Unlock()
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			nodelog(portno).Error("the metrics could not be served","error",err)
+		}
+	}()
+}
+
+/*This function is used to close the metrics server of a node that has left the ring and to drop its metrics, so that a node
+*that joins later on the same port starts with its own.
+*input: The port no of the node.*/
+func stopmetrics(portno int) {
+	metricslock.Lock()
+	defer metricslock.Unlock()
+	if server, ok := metricservers[portno]; ok {
+		server.Close()
+		delete(metricservers,portno)
+	}
+	delete(metrics,portno)
+}
+
+/*This function is used to write the answer of the ping, health and ready endpoints.
+*input: The writer, the HTTP status and the answer.*/
+func writehealth(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type","application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+/*This function is used to write the metrics of a node in the Prometheus text format. A node whose metrics have been dropped
+*is written with empty metrics, which are not kept.
+*input: The writer and the port no of the node.*/
+func writemetrics(w io.Writer, portno int) {
+	node := strconv.Itoa(portno)
+	ringlock.Lock()
+	server, ok := servermap[portno]
+	var keys, bytes int64
+	for _,v := range server.data {
+		keys++
+		bytes += v.size
+	}
+	var last int64
+	if changes, ok := changelogs[portno]; ok {
+		last = changes.seq
+	}
+	ringlock.Unlock()
+	metricslock.Lock()
+	defer metricslock.Unlock()
+	m, found := metrics[portno]
+	if !found {
+		m = newnodemetrics()
+	}
+
+	fmt.Fprintln(w,"# HELP dict3_up Whether the node is part of the ring.")
+	fmt.Fprintln(w,"# TYPE dict3_up gauge")
+	up := 0
+	if ok {
+		up = 1
+	}
+	fmt.Fprintf(w,"dict3_up{node=%q} %d\n",node,up)
+	fmt.Fprintln(w,"# HELP dict3_requests_total The number of calls of every function by result.")
+	fmt.Fprintln(w,"# TYPE dict3_requests_total counter")
+	requests := [][2]string{}
+	for r := range m.requests {
+		requests = append(requests,r)
+	}
+	sort.Slice(requests,func(i, j int) bool {
+		return requests[i][0]+requests[i][1] < requests[j][0]+requests[j][1]
+	})
+	for _,r := range requests {
+		fmt.Fprintf(w,"dict3_requests_total{node=%q,method=%q,result=%q} %d\n",node,r[0],r[1],m.requests[r])
+	}
+	fmt.Fprintln(w,"# HELP dict3_request_duration_seconds The latency of the calls of every function.")
+	fmt.Fprintln(w,"# TYPE dict3_request_duration_seconds histogram")
+	methods := []string{}
+	for method := range m.durations {
+		methods = append(methods,method)
+	}
+	sort.Strings(methods)
+	for _,method := range methods {
+		writehistogram(w,"dict3_request_duration_seconds","node="+strconv.Quote(node)+",method="+strconv.Quote(method),m.durations[method])
+	}
+	fmt.Fprintln(w,"# HELP dict3_lookup_hops The number of finger hops of the successor lookups started at the node.")
+	fmt.Fprintln(w,"# TYPE dict3_lookup_hops histogram")
+	writehistogram(w,"dict3_lookup_hops","node="+strconv.Quote(node),m.hops)
+	fmt.Fprintln(w,"# HELP dict3_keys The number of triplets stored on the node.")
+	fmt.Fprintln(w,"# TYPE dict3_keys gauge")
+	fmt.Fprintf(w,"dict3_keys{node=%q} %d\n",node,keys)
+	fmt.Fprintln(w,"# HELP dict3_bytes The number of bytes of the values stored on the node.")
+	fmt.Fprintln(w,"# TYPE dict3_bytes gauge")
+	fmt.Fprintf(w,"dict3_bytes{node=%q} %d\n",node,bytes)
+	fmt.Fprintln(w,"# HELP dict3_successor_changes_total The number of times the successor of the node has changed.")
+	fmt.Fprintln(w,"# TYPE dict3_successor_changes_total counter")
+	fmt.Fprintf(w,"dict3_successor_changes_total{node=%q} %d\n",node,m.successorchanges)
+	fmt.Fprintln(w,"# HELP dict3_finger_changes_total The number of times a finger of the node has changed.")
+	fmt.Fprintln(w,"# TYPE dict3_finger_changes_total counter")
+	fmt.Fprintf(w,"dict3_finger_changes_total{node=%q} %d\n",node,m.fingerchanges)
+	fmt.Fprintln(w,"# HELP dict3_changes_total The number of changes of the triplets of the node, expire counting the deletions of the purge.")
+	fmt.Fprintln(w,"# TYPE dict3_changes_total counter")
+	ops := []string{}
+	for op := range m.changes {
+		ops = append(ops,op)
+	}
+	sort.Strings(ops)
+	for _,op := range ops {
+		fmt.Fprintf(w,"dict3_changes_total{node=%q,op=%q} %d\n",node,op,m.changes[op])
+	}
+	fmt.Fprintln(w,"# HELP dict3_cdc_last_sequence The sequence number of the last change in the change log of the node.")
+	fmt.Fprintln(w,"# TYPE dict3_cdc_last_sequence gauge")
+	fmt.Fprintf(w,"dict3_cdc_last_sequence{node=%q} %d\n",node,last)
+	fmt.Fprintln(w,"# HELP dict3_cdc_consumer_lag The number of changes of the node a consumer of the change log has not read yet.")
+	fmt.Fprintln(w,"# TYPE dict3_cdc_consumer_lag gauge")
+	consumers := []string{}
+	for consumer := range m.consumers {
+		consumers = append(consumers,consumer)
+	}
+	sort.Strings(consumers)
+	for _,consumer := range consumers {
+		lag := last-m.consumers[consumer]
+		if lag < 0 {
+			lag = 0
+		}
+		fmt.Fprintf(w,"dict3_cdc_consumer_lag{node=%q,consumer=%q} %d\n",node,consumer,lag)
+	}
+}
+
+/*This function is used to write a histogram in the Prometheus text format.
+*input: The writer, the name and the labels of the histogram and the histogram.*/
+func writehistogram(w io.Writer, name string, labels string, h *histogram) {
+	for i,bound := range h.bounds {
+		fmt.Fprintf(w,"%s_bucket{%s,le=%q} %d\n",name,labels,strconv.FormatFloat(bound,'g',-1,64),h.counts[i])
+	}
+	fmt.Fprintf(w,"%s_bucket{%s,le=\"+Inf\"} %d\n",name,labels,h.count)
+	fmt.Fprintf(w,"%s_sum{%s} %s\n",name,labels,strconv.FormatFloat(h.sum,'g',-1,64))
+	fmt.Fprintf(w,"%s_count{%s} %d\n",name,labels,h.count)
+}
+
+/*This function is used to return the identity of the client of a request, its user when the request is authenticated and its IP
+*address otherwise.
+*input: The input of the request.
+*output: The identity of the client.*/
+func identity(input *JsonMessage) string {
+	if len(input.User) != 0 {
+		return input.User
+	}
+	host, _, err := net.SplitHostPort(input.Client)
+	if err != nil {
+		return input.Client
+	}
+	return host
+}
+
+/*This function is used to check the rate limits and quotas of the config file and to map the rate limits of the functions to
+*the functions of the server.
+*output: The error if a limit is invalid or names a function that does not exist.*/
+func initlimits() error {
+	limits := &serverconfig.Limits
+	if limits.Client.Rate < 0 {
+		return errors.New("Config error - The rate of the clients cannot be negative")
+	}
+	if limits.Client.Burst <= 0 {
+		limits.Client.Burst = int(math.Ceil(limits.Client.Rate))
+	}
+	methods := dict3methods()
+	for name,limit := range limits.Methods {
+		if limit.Rate < 0 {
+			return errors.New("Config error - The rate of the method "+name+" cannot be negative")
+		}
+		if limit.Burst <= 0 {
+			limit.Burst = int(math.Ceil(limit.Rate))
+		}
+		found := false
+		for _,method := range methods {
+			if strings.EqualFold(name,method) {
+				if limit.Rate > 0 {
+					methodlimits["Dict3."+method] = limit
+				}
+				found = true
+			}
+		}
+		if !found {
+			return errors.New("Config error - The method "+name+" of the rate limits does not exist at the server")
+		}
+	}
+	for _,q := range limits.Quotas {
+		if q.Entries < 0 || q.Bytes < 0 {
+			return errors.New("Config error - The quota of the prefix "+strconv.Quote(q.Prefix)+" cannot be negative")
+		}
+	}
+	return nil
+}
+
+/*This function is used to refill a token bucket up to the time of a call. The caller holds the bucket lock.
+*input: The name of the bucket, its rate limit and the time of the call.
+*output: The bucket.*/
+func refill(name string, limit RateType, now time.Time) *bucket {
+	b, ok := buckets[name]
+	if !ok {
+		b = &bucket{float64(limit.Burst),now}
+		buckets[name] = b
+	}
+	b.tokens = math.Min(float64(limit.Burst),b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	return b
+}
+
+/*This function is used to apply the rate limits of the client of a request. A call takes a token of the bucket of the client
+*and of the bucket of the client for the function, and is rejected without taking any token if either of them is empty.
+*input: The name of the function and the input of the request.
+*output: The error if the client has exceeded a rate limit.*/
+func ratelimit(method string, input *JsonMessage) error {
+	client := identity(input)
+	limits := []RateType{}
+	names := []string{}
+	if limit := serverconfig.Limits.Client; limit.Rate > 0 {
+		limits = append(limits,limit)
+		names = append(names,client)
+	}
+	if limit, ok := methodlimits[method]; ok {
+		limits = append(limits,limit)
+		names = append(names,client+"\n"+method)
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	now := time.Now()
+	bucketlock.Lock()
+	defer bucketlock.Unlock()
+	taken := []*bucket{}
+	for i,limit := range limits {
+		b := refill(names[i],limit,now)
+		if b.tokens < 1 {
+			for _,t := range taken {
+				t.tokens++
+			}
+			if i == 0 && names[i] == client {
+				return errors.New("Rate error - The client "+client+" has exceeded "+strconv.FormatFloat(limit.Rate,'f',-1,64)+" calls per second")
+			}
+			return errors.New("Rate error - The client "+client+" has exceeded "+strconv.FormatFloat(limit.Rate,'f',-1,64)+" calls per second of "+strings.TrimPrefix(method,"Dict3."))
+		}
+		b.tokens--
+		taken = append(taken,b)
+	}
+	return nil
+}
+
+/*This function is used to count a triplet that is stored on or removed from a node in the usage of the storage quotas it
+*counts against. The caller holds the ring lock.
+*input: The port no of the node, the triplet and 1 when it is stored or -1 when it is removed.*/
+func countquota(portno int, k datakey, v datavalue, sign int) {
+	for i,q := range serverconfig.Limits.Quotas {
+		counter := quotacounter{i,""}
+		if q.Client == "*" {
+			counter.client = v.creator
+		}
+		if !quotamatches(q,counter.client,k,v) {
+			continue
+		}
+		counts, ok := quotacounts[portno]
+		if !ok {
+			counts = make(map[quotacounter]quotacount)
+			quotacounts[portno] = counts
+		}
+		count := counts[counter]
+		count.entries += sign
+		count.bytes += int64(sign)*v.size
+		if count.entries == 0 {
+			delete(counts,counter)
+		} else {
+			counts[counter] = count
+		}
+	}
+}
+
+/*This function is used to return the usage of a storage quota by the triplets of the ring. Expired triplets count until they
+*are deleted by the sweeper of their node.
+*input: The index of the quota and the client the quota is evaluated for when it gives each client its own quota.
+*output: The number of triplets and bytes that count against the quota.*/
+func quotausage(quota int, client string) quotacount {
+	counter := quotacounter{quota,""}
+	if serverconfig.Limits.Quotas[quota].Client == "*" {
+		counter.client = client
+	}
+	var usage quotacount
+	for _,counts := range quotacounts {
+		count := counts[counter]
+		usage.entries += count.entries
+		usage.bytes += count.bytes
+	}
+	return usage
+}
+
+/*This function is used to find the triplet of the ring with the given key, which is stored on its owner.
+*input: The key of the triplet.
+*output: The triplet, nil if the ring does not store it.*/
+func storedvalue(k datakey) *datavalue {
+	for _,server := range servermap {
+		if v, ok := server.data[k]; ok {
+			return &v
+		}
+	}
+	return nil
+}
+
+/*This function is used to check whether a triplet counts against a storage quota.
+*input: The quota, the client the quota is evaluated for, and the triplet.
+*output: True if the triplet counts against the quota.*/
+func quotamatches(q QuotaType, client string, k datakey, v datavalue) bool {
+	if !strings.HasPrefix(k.key,q.Prefix) {
+		return false
+	}
+	switch q.Client {
+	case "":
+		return true
+	case "*":
+		return v.creator == client
+	}
+	return v.creator == q.Client
+}
+
+/*This function is used to check a set of changes against the storage quotas. The changes are rejected if a quota would be
+*exceeded and its usage would grow, so that triplets can always be deleted or made smaller.
+*input: The changes.
+*output: The error if the changes exceed a quota.*/
+func checkquota(changes []quotachange) error {
+	for i,q := range serverconfig.Limits.Quotas {
+		clients := []string{q.Client}
+		if q.Client == "*" {
+			clients = clients[:0]
+			seen := make(map[string]bool)
+			for _,c := range changes {
+				if c.v != nil && !seen[c.v.creator] {
+					seen[c.v.creator] = true
+					clients = append(clients,c.v.creator)
+				}
+			}
+		}
+		for _,client := range clients {
+			usage := quotausage(i,client)
+			entries, bytes := usage.entries,usage.bytes
+			changed := make(map[datakey]*datavalue)
+			for _,c := range changes {
+				old, ok := changed[c.k]
+				if !ok {
+					old = storedvalue(c.k)
+				}
+				if old != nil && quotamatches(q,client,c.k,*old) {
+					usage.entries--
+					usage.bytes -= old.size
+				}
+				if c.v != nil && quotamatches(q,client,c.k,*c.v) {
+					usage.entries++
+					usage.bytes += c.v.size
+				}
+				changed[c.k] = c.v
+			}
+			newentries, newbytes := usage.entries,usage.bytes
+			name := "prefix "+strconv.Quote(q.Prefix)
+			if q.Client == "*" {
+				name += " of the client "+client
+			} else if len(q.Client) != 0 {
+				name += " of the client "+q.Client
+			}
+			if q.Entries > 0 && newentries > q.Entries && newentries > entries {
+				return errors.New("Quota error - The quota of the "+name+" allows "+strconv.Itoa(q.Entries)+" triplets")
+			}
+			if q.Bytes > 0 && newbytes > q.Bytes && newbytes > bytes {
+				return errors.New("Quota error - The quota of the "+name+" allows "+strconv.FormatInt(q.Bytes,10)+" bytes, the change would store "+strconv.FormatInt(newbytes,10))
+			}
+		}
+	}
+	return nil
+}
+
+/*The functions that change the ring and are written to the audit log.*/
+var auditedmethods = map[string]bool{
+	"Dict3.Insert": true,
+	"Dict3.InsertOrUpdate": true,
+	"Dict3.CompareAndSwap": true,
+	"Dict3.Delete": true,
+	"Dict3.Chmod": true,
+	"Dict3.Transaction": true,
+	"Dict3.Purge": true,
+	"Dict3.Import": true,
+	"Dict3.Restore": true,
+	"Dict3.Verify": true,
+	"Dict3.Shutdown": true,
+	"Dict3.AddNode": true,
+}
+
+/*This function is used to write a call of a function to the audit log. The owners of the triplets named in the input are the
+*nodes that own them once the call has completed.
+*input: The request and the error of the call, empty if it succeeded.*/
+func audit(pending *auditrequest, callerror string) {
+	input := pending.input
+	r := AuditRecord{formattimestamp(time.Now()),strings.TrimPrefix(pending.method,"Dict3."),input.Params,input.Override,input.Client,input.User,input.RequestID,input.Portno,nil,"ok","",float64(time.Since(pending.start).Microseconds())/1000}
+	if len(callerror) != 0 {
+		r.Result, r.Error = "error",callerror
+	}
+	if r.Params == nil {
+		r.Params = []interface{}{}
+	}
+	ringlock.Lock()
+	if pending.method == "Dict3.Transaction" {
+		if ops, err := txops(input); err == nil {
+			for _,op := range ops {
+				r.Owners = append(r.Owners,ringsuccessor(DataHash(op.Key,op.Relationship)))
+			}
+		}
+	} else if params, err := stringparams(input,0,1); err == nil && len(strings.TrimSpace(params[0])) != 0 && len(strings.TrimSpace(params[1])) != 0 && len(ringmap) > 0 {
+		r.Owners = []int{ringsuccessor(DataHash(strings.TrimSpace(params[0]),strings.TrimSpace(params[1])))}
+	}
+	ringlock.Unlock()
+	if err := writeaudit(r); err != nil {
+		nodelog(input.Portno).Error("the audit log could not be written","request",input.RequestID,"error",err)
+	}
+}
+
+/*This function is used to append an entry to the audit log. When the log has reached its maximum size it is rotated first:
+*audit.log is renamed to audit.log.1, audit.log.1 to audit.log.2 and so on, and the oldest backup is removed.
+*input: The entry.
+*output: The error if any.*/
+func writeaudit(r AuditRecord) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	auditlock.Lock()
+	defer auditlock.Unlock()
+	if info, err := os.Stat(serverconfig.Audit.File); err == nil && info.Size()+int64(len(line)) > serverconfig.Audit.MaxSize {
+		os.Remove(serverconfig.Audit.File+"."+strconv.Itoa(serverconfig.Audit.Backups))
+		for i := serverconfig.Audit.Backups-1; i >= 1; i-- {
+			os.Rename(serverconfig.Audit.File+"."+strconv.Itoa(i),serverconfig.Audit.File+"."+strconv.Itoa(i+1))
+		}
+		if serverconfig.Audit.Backups > 0 {
+			if err := os.Rename(serverconfig.Audit.File,serverconfig.Audit.File+".1"); err != nil {
+				return err
+			}
+		} else if err := os.Remove(serverconfig.Audit.File); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(serverconfig.Audit.File),0770); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(serverconfig.Audit.File,os.O_WRONLY|os.O_CREATE|os.O_APPEND,0660)
+	if err != nil {
+		return err
+	}
+	if _,err := file.Write(append(line,'\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+/*This function is used to query the audit log and its backups from the command line, oldest entries first. Every filter has the
+*form name=value: op, client (a prefix of the address), node, owner, key, relationship, result ("ok" or "error"), since and
+*until (RFC3339) and limit (the number of most recent matching entries to print).
+*input: The filters and the writer the matching entries are printed to.
+*output: The error if a filter is not valid or the log cannot be read.*/
+func QueryAudit(filters []string, out io.Writer) error {
+	match := map[string]string{}
+	var since, until time.Time
+	limit := 0
+	for _,f := range filters {
+		parts := strings.SplitN(f,"=",2)
+		if len(parts) != 2 {
+			return errors.New("invalid filter "+strconv.Quote(f)+", filters have the form name=value")
+		}
+		var err error
+		switch parts[0] {
+		case "op", "client", "node", "owner", "key", "relationship", "result":
+			match[parts[0]] = parts[1]
+		case "since":
+			since, err = time.Parse(time.RFC3339,parts[1])
+		case "until":
+			until, err = time.Parse(time.RFC3339,parts[1])
+		case "limit":
+			limit, err = strconv.Atoi(parts[1])
+		default:
+			return errors.New("unknown filter "+strconv.Quote(parts[0]))
+		}
+		if err != nil {
+			return errors.New("invalid filter "+strconv.Quote(f)+" - "+err.Error())
+		}
+	}
+	files := []string{}
+	for i := serverconfig.Audit.Backups; i >= 1; i-- {
+		files = append(files,serverconfig.Audit.File+"."+strconv.Itoa(i))
+	}
+	files = append(files,serverconfig.Audit.File)
+	matched := [][]byte{}
+	for _,name := range files {
+		file, err := os.Open(name)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte,64*1024),64*1024*1024)
+		for scanner.Scan() {
+			var r AuditRecord
+			if json.Unmarshal(scanner.Bytes(),&r) != nil || !auditmatches(r,match,since,until) {
+				continue
+			}
+			matched = append(matched,append([]byte{},scanner.Bytes()...))
+		}
+		file.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	for _,line := range matched {
+		if _,err := fmt.Fprintf(out,"%s\n",line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*This function is used to check whether an entry of the audit log matches the filters of a query.
+*input: The entry, the filters on its fields and the time range, a zero time being unbounded.
+*output: The boolean which indicates whether the entry matches.*/
+func auditmatches(r AuditRecord, match map[string]string, since time.Time, until time.Time) bool {
+	t, _ := parsetimestamp(r.Time)
+	if (!since.IsZero() && t.Before(since)) || (!until.IsZero() && t.After(until)) {
+		return false
+	}
+	param := func(i int) string {
+		if i < len(r.Params) {
+			if s, ok := r.Params[i].(string); ok {
+				return strings.TrimSpace(s)
+			}
+		}
+		return ""
+	}
+	for name,value := range match {
+		switch name {
+		case "op":
+			if !strings.EqualFold(r.Op,value) { return false }
+		case "client":
+			if !strings.HasPrefix(r.Client,value) { return false }
+		case "node":
+			if strconv.Itoa(r.Node) != value { return false }
+		case "owner":
+			found := false
+			for _,owner := range r.Owners {
+				found = found || strconv.Itoa(owner) == value
+			}
+			if !found { return false }
+		case "key":
+			if param(0) != value { return false }
+		case "relationship":
+			if param(1) != value { return false }
+		case "result":
+			if r.Result != value { return false }
+		}
+	}
+	return true
+}
+
+/*The role that is needed to call every function. Functions that are not listed need the admin role.*/
+var methodroles = map[string]string{
+	"Dict3.Describe": "read",
+	"Dict3.Quota": "read",
+	"Dict3.Ping": "read",
+	"Dict3.Health": "read",
+	"Dict3.Ready": "read",
+	"Dict3.LookUp": "read",
+	"Dict3.Stat": "read",
+	"Dict3.ListKeys": "read",
+	"Dict3.ListIDs": "read",
+	"Dict3.Topology": "read",
+	"Dict3.Export": "read",
+	"Dict3.Watch": "read",
+	"Dict3.Poll": "read",
+	"Dict3.Unwatch": "read",
+	"Dict3.Changes": "read",
+	"Dict3.Insert": "write",
+	"Dict3.InsertOrUpdate": "write",
+	"Dict3.CompareAndSwap": "write",
+	"Dict3.Delete": "write",
+	"Dict3.Chmod": "write",
+	"Dict3.Transaction": "write",
+	"Dict3.Import": "write",
+}
+
+/*The roles ordered by what they allow, every role allows everything the roles before it allow.*/
+var roleranks = map[string]int{"read": 1, "write": 2, "admin": 3}
+
+/*This function is used to compute the signature of a request.
+*input: The function that is called, the input of the request, its authentication and the secret of the user.
+*output: The hex encoded signature.*/
+func signrequest(method string, input *JsonMessage, token *AuthToken, secret string) string {
+	//The params are normalized through a generic JSON value so that the client and the server sign the same bytes.
+	var params []interface{}
+	raw, _ := json.Marshal(input.Params)
+	json.Unmarshal(raw,&params)
+	body, _ := json.Marshal(signedbody{params,input.TTL,input.Override,input.ExpectedVersion})
+	mac := hmac.New(sha256.New,[]byte(secret))
+	mac.Write([]byte(method+"\n"+token.User+"\n"+strconv.FormatInt(token.Timestamp,10)+"\n"+token.Nonce+"\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+/*This function is used to authenticate a request and to check that the user is allowed to call the function. Requests are
+*not checked when the authentication is disabled, and when no users are configured only the functions of the read role can be called.
+*input: The function that is called and the input of the request.
+*output: The error the request is rejected with, if any.*/
+func authenticate(method string, input *JsonMessage) error {
+	if serverconfig.Auth.Disabled {
+		return nil
+	}
+	if len(serverconfig.Auth.Users) == 0 {
+		if role, ok := methodroles[method]; !ok || input.Override || role != "read" {
+			return errors.New("Auth error - No users are configured, add users to the auth config of the server to call "+strings.TrimPrefix(method,"Dict3.")+" or set disabled in it")
+		}
+		return nil
+	}
+	token := input.Auth
+	if token == nil || len(token.User) == 0 {
+		return errors.New("Auth error - The request is not authenticated, add the credentials to the client config file")
+	}
+	var user *AuthUser
+	for i := range serverconfig.Auth.Users {
+		if serverconfig.Auth.Users[i].User == token.User {
+			user = &serverconfig.Auth.Users[i]
+		}
+	}
+	if user == nil || !hmac.Equal([]byte(signrequest(method,input,token,user.Secret)),[]byte(token.Signature)) {
+		return errors.New("Auth error - Invalid user or signature")
+	}
+	skew := time.Duration(serverconfig.Auth.MaxSkew)*time.Second
+	if age := time.Since(time.Unix(token.Timestamp,0)); age > skew || age < -skew {
+		return errors.New("Auth error - The request has expired, check the clock of the client")
+	}
+	noncelock.Lock()
+	for nonce,seen := range nonces {
+		if time.Since(seen) > 2*skew {
+			delete(nonces,nonce)
+		}
+	}
+	if _,replayed := nonces[token.User+"\n"+token.Nonce]; replayed {
+		noncelock.Unlock()
+		return errors.New("Auth error - The request has already been received")
+	}
+	nonces[token.User+"\n"+token.Nonce] = time.Now()
+	noncelock.Unlock()
+	role, ok := methodroles[method]
+	if !ok || input.Override {
+		role = "admin"
+	}
+	if roleranks[user.Role] < roleranks[role] {
+		return errors.New("Auth error - The user "+user.User+" with the role "+strconv.Quote(user.Role)+" is not allowed to call "+strings.TrimPrefix(method,"Dict3.")+", it needs the role "+strconv.Quote(role))
+	}
+	input.User = user.User
+	return nil
+}
+
+/*This function is used to snapshot every node and to close the write-ahead logs before the process exits.
+*output: The error if any of the nodes could not be snapshotted.*/
+func closestores() error {
+	for portno,store := range stores {
+		if err := store.snapshot(servermap[portno]); err != nil {
+			return err
+		}
+		if err := store.wal.Close(); err != nil {
+			return err
+		}
+	}
+	for _,changes := range changelogs {
+		if err := changes.file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*This function runs in the background. It syncs the write-ahead logs and change logs once a second when the "interval" fsync
+*policy is used and writes a snapshot of every node once the snapshot interval has passed.*/
+func snapshotter() {
+	ticker := time.NewTicker(time.Second)
+	last := time.Now()
+	for range ticker.C {
+		ringlock.Lock()
+		if serverconfig.Storage.Fsync == "interval" {
+			for portno,store := range stores {
+				if err := store.wal.Sync(); err != nil {
+					nodelog(portno).Error("the write-ahead log could not be synced","error",err)
+				}
+			}
+			for portno,changes := range changelogs {
+				if err := changes.file.Sync(); err != nil {
+					nodelog(portno).Error("the change log could not be synced","error",err)
+				}
+			}
+		}
+		if time.Since(last) >= time.Duration(serverconfig.Storage.SnapshotInterval)*time.Second {
+			for portno,store := range stores {
+				if err := store.snapshot(servermap[portno]); err != nil {
+					nodelog(portno).Error("the snapshot could not be written","error",err)
+				}
+			}
+			last = time.Now()
+		}
+		ringlock.Unlock()
+	}
+}
+
+/*This function is used to write all the data stored in the ring to the DICT3 file. The file is rewritten
+*on every save so that saving more than once does not duplicate the triplets, and every field is escaped
+*after a header that marks the file as escaped. The ttl in seconds, the version and the creator of every triplet
+*follow its permission so that a reload keeps them.
+*output: The error if the file could not be written.*/
+func saveDICT3() error {
+	outFile, err := os.OpenFile(serverconfig.PersistentStorageContainer.File, os.O_RDWR|os.O_TRUNC|os.O_CREATE,0660)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(outFile)
+	w.WriteString(tabfile.Header+"\n")
+	for _,value := range servermap {
+		for k,v := range value.data {
+			r := torecord(k,v)
+			fields := []string{r.Key,r.Relationship,r.Value,r.Size,r.Created,r.Modified,r.Accessed,r.Permission,strconv.FormatInt(*r.TTL,10),strconv.FormatInt(r.Version,10),r.Creator}
+			for i := range fields {
+				fields[i] = tabfile.Escape(fields[i])
+			}
+			if _,err := w.WriteString(strings.Join(fields,"\t")+"\n"); err != nil {
+				outFile.Close()
+				return err
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		outFile.Close()
+		return err
+	}
+	return outFile.Close()
+}
+
+/*This function is used to parse a single line of the DICT3 file. The tab separated lines written by this server
+*and the JSON lines written by the single node JSON-RPC server are both understood. Escaped lines hold either the 8 fields
+*of older servers or the ttl, the version and the creator as 3 more fields.
+*input: The line of the file and whether the file starts with the header of escaped files.
+*output: The triplet and its metadata or the reason the line cannot be loaded.*/
+func parseDICT3line(line string, escaped bool) (DICT3record, error) {
+	if strings.HasPrefix(strings.TrimSpace(line),"{") {
+		var row struct{
+			Key string
+			Relationship string
+			Value json.RawMessage
+			Size, Created, Modified, Accessed, Permission string
+			TTL *int64
+		}
+		if err := json.Unmarshal([]byte(line),&row); err != nil {
+			return DICT3record{},errors.New("invalid JSON - "+err.Error())
+		}
+		r := DICT3record{row.Key,row.Relationship,"",row.Size,row.Created,row.Modified,row.Accessed,row.Permission,row.TTL,0,"",""}
+		if err := json.Unmarshal(row.Value,&r.Value); err != nil {
+			r.Value = string(row.Value)
+		}
+		completerecord(&r)
+		return r,validaterecord(r)
+	}
+	fields := strings.Split(line,"\t")
+	if len(fields) < 8 {
+		return DICT3record{},errors.New("expected 8 tab separated fields, found "+strconv.Itoa(len(fields)))
+	}
+	if escaped {
+		for i := range fields {
+			fields[i] = tabfile.Unescape(fields[i])
+		}
+		if len(fields) == 11 {
+			ttl, err := strconv.ParseInt(fields[8],10,64)
+			if err != nil {
+				return DICT3record{},errors.New("invalid ttl "+strconv.Quote(fields[8]))
+			}
+			version, err := strconv.ParseInt(fields[9],10,64)
+			if err != nil || version < 0 {
+				return DICT3record{},errors.New("invalid version "+strconv.Quote(fields[9]))
+			}
+			r := DICT3record{fields[0],fields[1],fields[2],fields[3],fields[4],fields[5],fields[6],fields[7],&ttl,version,fields[10],""}
+			return r,validaterecord(r)
+		} else if len(fields) != 8 {
+			return DICT3record{},errors.New("expected 8 or 11 tab separated fields, found "+strconv.Itoa(len(fields)))
+		}
+	}
+	//Files written before the fields were escaped may contain raw tabs in the value, so everything between the relationship and the metadata is the value.
+	n := len(fields)
+	r := DICT3record{fields[0],fields[1],strings.Join(fields[2:n-5],"\t"),fields[n-5],fields[n-4],fields[n-3],fields[n-2],fields[n-1],nil,0,"",""}
+	return r,validaterecord(r)
+}
+
+/*This function is used to fill in the metadata that is missing from a record, e.g. a line written by the
+*single node JSON-RPC server. The triplet is treated as created and accessed now and is read-write.
+*input: The record to complete.*/
+func completerecord(r *DICT3record) {
+	now := formattimestamp(time.Now())
+	if len(r.Created) == 0 {
+		r.Created = now
+	}
+	if len(r.Accessed) == 0 {
+		r.Accessed = now
+	}
+	if len(r.Permission) == 0 {
+		r.Permission = "RW"
+	}
+	if len(r.Size) == 0 {
+		r.Size = strconv.Itoa(len(r.Value))
+	}
+}
+
+/*This function is used to check that a record read from the disk holds a complete triplet with valid metadata.
+*input: The record.
+*output: The reason the record is invalid, if any.*/
+func validaterecord(r DICT3record) error {
+	if len(strings.TrimSpace(r.Key)) == 0 || len(strings.TrimSpace(r.Relationship)) == 0 {
+		return errors.New("the key and the relationship cannot be empty")
+	}
+	if size, err := parsesize(r.Size); err != nil || size < 0 {
+		return errors.New("invalid size "+strconv.Quote(r.Size))
+	}
+	timestamps := []string{r.Created,r.Accessed}
+	if len(r.Modified) != 0 {
+		timestamps = append(timestamps,r.Modified)
+	}
+	for _,t := range timestamps {
+		if _,err := parsetimestamp(t); err != nil {
+			return errors.New("invalid timestamp "+strconv.Quote(t))
+		}
+	}
+	if len(strings.TrimSpace(r.Permission)) == 0 {
+		return errors.New("the permission cannot be empty")
+	}
+	if r.TTL != nil && *r.TTL < 0 {
+		return errors.New("the ttl cannot be negative")
+	}
+	return nil
+}
+
+/*This function is used to load the DICT3 file at startup and to store every triplet on its owner in the ring.
+*Triplets that are already on the ring are kept as they are. When a triplet appears more than once in the
+*file the last line wins, as older versions of the server appended to the file on every save. Lines of triplets
+*that are locked by a transaction or that would exceed a quota are rejected.
+*input: The name of the DICT3 file and the port no of the node the lookups start from.
+*output: The report of the loaded, skipped and rejected lines and the error if the file could not be read.*/
+func LoadDICT3(filename string, portno int) (LoadReport, error) {
+	report := LoadReport{}
+	inFile, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return report,nil
+	} else if err != nil {
+		return report,err
+	}
+	defer inFile.Close()
+	fromfile := make(map[datakey]bool)
+	scanner := bufio.NewScanner(inFile)
+	scanner.Buffer(make([]byte,64*1024),64*1024*1024)
+	line := 0
+	escaped := false
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSuffix(scanner.Text(),"\r")
+		if len(strings.TrimSpace(text)) == 0 {
+			continue
+		}
+		if line == 1 && text == tabfile.Header {
+			escaped = true
+			continue
+		}
+		r, err := parseDICT3line(text,escaped)
+		if err != nil {
+			report.Rejected = append(report.Rejected,RejectedRow{line,err.Error()})
+			continue
+		}
+		k,v := fromrecord(r)
+		k = datakey{strings.TrimSpace(k.key),strings.TrimSpace(k.relation)}
+		targetport := FindSuccessor(DataHash(k.key,k.relation),portno)
+		dupver = dupver[:0]
+		if _,ok := servermap[targetport].data[k]; ok && !fromfile[k] {
+			report.Skipped++
+			continue
+		}
+		if err := checklock(targetport,k); err != nil {
+			report.Rejected = append(report.Rejected,RejectedRow{line,err.Error()})
+			continue
+		}
+		if err := checkquota([]quotachange{{k,&v}}); err != nil {
+			report.Rejected = append(report.Rejected,RejectedRow{line,err.Error()})
+			continue
+		}
+		if next := nextversion(targetport,k); !fromfile[k] && v.version < next {
+			v.version = next
+		}
+		if err := storeEntry("",targetport,k,v); err != nil {
+			return report,err
+		}
+		if !fromfile[k] {
+			report.Loaded++
+		}
+		fromfile[k] = true
+	}
+	return report,scanner.Err()
+}
+
+/*This function is used to write the partition of every node and the ring membership to an archive directory.
+*The archive is written to a temporary directory first and renamed once it is complete, the temporary directory is removed
+*if the archive cannot be written.
+*input: The directory of the archive.
+*output: The manifest of the archive and the error if any.*/
+func WriteArchive(dir string) (manifest ArchiveManifest, err error) {
+	manifest = ArchiveManifest{1,time.Now().UTC().Format(time.RFC3339),ringsize,[]ArchivePartition{}}
+	if _,err := os.Stat(dir); err == nil {
+		return manifest,errors.New("the archive "+dir+" already exists")
+	}
+	tmpdir := dir+".tmp"
+	os.RemoveAll(tmpdir)
+	defer func() {
+		if err != nil {
+			os.RemoveAll(tmpdir)
+		}
+	}()
+	if err := os.MkdirAll(tmpdir,0770); err != nil {
+		return manifest,err
+	}
+	for _,node := range BuildTopology().Nodes {
+		partition := ArchivePartition{node.ID,node.Port,node.Successor,node.Predecessor,"node-"+strconv.Itoa(node.Port)+".jsonl",0,""}
+		outFile, err := os.Create(filepath.Join(tmpdir,partition.File))
+		if err != nil {
+			return manifest,err
+		}
+		hash := sha256.New()
+		w := bufio.NewWriter(io.MultiWriter(outFile,hash))
+		encoder := json.NewEncoder(w)
+		for k,v := range servermap[node.Port].data {
+			if err := encoder.Encode(torecord(k,v)); err != nil {
+				outFile.Close()
+				return manifest,err
+			}
+			partition.Entries++
+		}
+		if err := w.Flush(); err != nil {
+			outFile.Close()
+			return manifest,err
+		}
+		if err := outFile.Sync(); err != nil {
+			outFile.Close()
+			return manifest,err
+		}
+		outFile.Close()
+		partition.Checksum = hex.EncodeToString(hash.Sum(nil))
+		manifest.Partitions = append(manifest.Partitions,partition)
+	}
+	out, err := json.MarshalIndent(manifest,"","  ")
+	if err != nil {
+		return manifest,err
+	}
+	if err := os.WriteFile(filepath.Join(tmpdir,"manifest.json"),append(out,'\n'),0660); err != nil {
+		return manifest,err
+	}
+	return manifest,os.Rename(tmpdir,dir)
+}
+
+/*This function is used to read an archive and to verify the checksum of every partition in it.
+*input: The directory of the archive.
+*output: The manifest of the archive, all the triplets in it and the error if the archive is incomplete or corrupted.*/
+func ReadArchive(dir string) (ArchiveManifest, []DICT3record, error) {
+	var manifest ArchiveManifest
+	in, err := os.ReadFile(filepath.Join(dir,"manifest.json"))
+	if err != nil {
+		return manifest,nil,err
+	}
+	if err := json.Unmarshal(in,&manifest); err != nil {
+		return manifest,nil,errors.New("the manifest is not valid: "+err.Error())
+	}
+	if manifest.Version != 1 {
+		return manifest,nil,errors.New("unsupported archive version "+strconv.Itoa(manifest.Version))
+	}
+	records := []DICT3record{}
+	for _,partition := range manifest.Partitions {
+		content, err := os.ReadFile(filepath.Join(dir,filepath.Base(partition.File)))
+		if err != nil {
+			return manifest,nil,err
+		}
+		sum := sha256.Sum256(content)
+		if hex.EncodeToString(sum[:]) != partition.Checksum {
+			return manifest,nil,errors.New("the checksum of the partition "+partition.File+" does not match")
+		}
+		scanner := bufio.NewScanner(strings.NewReader(string(content)))
+		scanner.Buffer(make([]byte,64*1024),64*1024*1024)
+		entries := 0
+		for scanner.Scan() {
+			var r DICT3record
+			if err := json.Unmarshal(scanner.Bytes(),&r); err != nil {
+				return manifest,nil,errors.New("the partition "+partition.File+" is not valid: "+err.Error())
+			}
+			records = append(records,r)
+			entries++
+		}
+		if entries != partition.Entries {
+			return manifest,nil,errors.New("the partition "+partition.File+" holds "+strconv.Itoa(entries)+" entries, expected "+strconv.Itoa(partition.Entries))
+		}
+	}
+	return manifest,records,nil
+}
+
+func InitializeRing(){
+  ringsize = 128
+	ringval = 0
+  ringmap = make(map[int]int)
+	close = make(map[int]bool)
+}
+
+/*This contains a function that is used to add the server to the chord ring.
+*input: This input refers to the number of servers to add to the ring.*/
+func AddtoRing(in Server) {
+	inHash := createhash(strconv.Itoa(in.portno))
+  if _,ok := ringmap[inHash];ok {
+    for i := ringval; i < ringsize; i++{
+      if _,yes :=  ringmap[i]; yes {
+        continue
+      }
+      ringmap[i] = in.portno
+      ringval = i
+      break
+    }
+  } else {
+    ringmap[inHash]=in.portno
+  }
+}
+
+/*This contains a function that is used to start the server with the corresponding Port number. The connections are accepted
+*in the background.
+*input: This input refers to the port number of the server
+*output: The error if the server cannot listen on the port.*/
+func startserver(portno int) error {
+	//Listening for any active tcp connection at the specified port address.
+	listener, err := clienttransport.Listen(portno)
+	if err != nil {
+		return err
+	}
+
+	//Every connection is served on its own so that a client waiting in a poll does not block the other clients.
+	go func() {
+		for close[portno] {
+			conn, err := listener.Accept()
+			if err != nil {
+				nodelog(portno).Warn("a connection could not be accepted","error",err)
+				continue
+			}
+			go rpc.ServeCodec(newNodeCodec(conn,portno))
+		}
+	}()
+	return nil
+}
+
+/*This function is used to load a certificate, its private key and a certificate authority for a TLS configuration.
+*input: The paths of the certificate, the key and the CA, the CA is optional.
+*output: The TLS configuration and the error if any of the files cannot be loaded.*/
+func loadtls(cert string, key string, ca string) (*tls.Config, error) {
+	pair, err := tls.LoadX509KeyPair(cert,key)
+	if err != nil {
+		return nil,err
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{pair}, MinVersion: tls.VersionTLS12}
+	if len(ca) != 0 {
+		pem, err := os.ReadFile(ca)
+		if err != nil {
+			return nil,err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil,errors.New("no certificate found in "+ca)
+		}
+		config.RootCAs = pool
+		config.ClientCAs = pool
+	}
+	return config,nil
+}
+
+/*This function is used to load the TLS configurations of the client port and of the traffic between the nodes.
+*output: The error if a configuration is incomplete or cannot be loaded.*/
+func inittls() error {
+	if len(serverconfig.TLS.Cert) != 0 || len(serverconfig.TLS.Key) != 0 {
+		config, err := loadtls(serverconfig.TLS.Cert,serverconfig.TLS.Key,serverconfig.TLS.CA)
+		if err != nil {
+			return errors.New("TLS error - "+err.Error())
+		}
+		if serverconfig.TLS.ClientAuth {
+			if len(serverconfig.TLS.CA) == 0 {
+				return errors.New("TLS error - clientauth needs the CA the client certificates are signed by")
+			}
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		clienttls = config
+	}
+	if len(serverconfig.NodeTLS.Cert) != 0 || len(serverconfig.NodeTLS.Key) != 0 || len(serverconfig.NodeTLS.CA) != 0 {
+		if len(serverconfig.NodeTLS.CA) == 0 {
+			return errors.New("TLS error - the traffic between the nodes needs the CA the node certificates are signed by")
+		}
+		config, err := loadtls(serverconfig.NodeTLS.Cert,serverconfig.NodeTLS.Key,serverconfig.NodeTLS.CA)
+		if err != nil {
+			return errors.New("TLS error - "+err.Error())
+		}
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		nodetls = config
+	}
+	return nil
+}
+
+/*This function listens for the calls of the other nodes on the transport of the nodes and serves the Node functions of the node
+*to them in the background until the node stops listening.
+*input: The port no of the node.
+*output: The error if the node cannot listen for the other nodes.*/
+func startnodeserver(portno int) error {
+	listener, err := nodetransport.Listen(portno)
+	if err != nil {
+		return err
+	}
+	nodelisteners[portno] = listener
+	node := Node(portno)
+	server := rpc.NewServer()
+	server.RegisterName("Node",&node)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if errors.Is(err,net.ErrClosed) {
+				return
+			}
+			if err != nil {
+				nodelog(portno).Warn("a connection of a node could not be accepted","error",err)
+				continue
+			}
+			go server.ServeCodec(jsonrpc.NewServerCodec(conn))
+		}
+	}()
+	return nil
+}
+
+/*This function is used to stop a node from listening for the other nodes.
+*input: The port no of the node.*/
+func stopnodeserver(portno int) {
+	if listener, ok := nodelisteners[portno]; ok {
+		listener.Close()
+		delete(nodelisteners,portno)
+	}
+}
+
+/*This function is used to call a function of another node over the transport of the nodes. The functions the nodes call on
+*each other can be repeated, so a call that fails other than with an error of the function is tried again a few times.
+*input: The port nos of the calling and of the called node, the function, its input and its output.
+*output: The error of the last attempt.*/
+func callnode(from int, to int, method string, args interface{}, reply interface{}) error {
+	var err error
+	for attempt := 0; attempt < nodeattempts; attempt++ {
+		err = nodetransport.Call(from,to,method,args,reply)
+		if _,failed := err.(rpc.ServerError); err == nil || failed {
+			return err
+		}
+	}
+	return err
+}
+
+/*This function is used to call a function of another node while the caller holds the ring lock. The lock is released during
+*the call, as the called node takes it to answer, and is held again when the function returns.
+*input: The port nos of the calling and of the called node, the function, its input and its output.
+*output: The error of the call.*/
+func ringcall(from int, to int, method string, args interface{}, reply interface{}) error {
+	ringlock.Unlock()
+	defer ringlock.Lock()
+	return callnode(from,to,method,args,reply)
+}
+
+/*This function is used by the functions of the clients to take the ring lock. The membership of the ring is shared with the
+*other functions of the clients, so that no node joins or leaves the ring while the ring lock is released during a lookup.
+*output: The function that releases the locks.*/
+func lockring() func() {
+	memberlock.RLock()
+	ringlock.Lock()
+	return func() {
+		ringlock.Unlock()
+		memberlock.RUnlock()
+	}
+}
+
+/*This function is used to take the ring lock to add nodes to the ring, to remove them or to merge rings. The membership of the
+*ring is not shared, so no function of a client runs until the nodes have handed over their triplets.
+*output: The function that releases the locks.*/
+func lockmembers() func() {
+	memberlock.Lock()
+	ringlock.Lock()
+	return func() {
+		ringlock.Unlock()
+		memberlock.Unlock()
+	}
+}
+
+/*A transport connects the Chord nodes: every node listens on it for the other nodes, which call its Node functions through it
+*with JSON-RPC. The connections of the clients are accepted through a transport as well.
+*Listen: Returns the listener of a node.
+*Dial: Connects a node to another node.
+*Call: Calls a function of another node and waits for its result.*/
+type Transport interface{
+	Listen(portno int) (net.Listener, error)
+	Dial(from int, to int) (net.Conn, error)
+	Call(from int, to int, method string, args interface{}, reply interface{}) error
+}
+
+/*The clients a transport keeps open to the other nodes, by port no. A client is closed when a call fails with anything but an
+*error of the called function.*/
+type rpcpool struct{
+	lock sync.Mutex
+	clients map[int]*rpc.Client
+}
+
+/*This function is used to call a function of a node with a client of the pool, which is dialed through the transport if there
+*is none.
+*input: The transport, the port nos of the calling and of the called node, the function, its input and its output.
+*output: The error of the connection or of the function.*/
+func (p *rpcpool) call(t Transport, from int, to int, method string, args interface{}, reply interface{}) error {
+	p.lock.Lock()
+	client, ok := p.clients[to]
+	p.lock.Unlock()
+	if !ok {
+		conn, err := t.Dial(from,to)
+		if err != nil {
+			return err
+		}
+		client = jsonrpc.NewClient(conn)
+		p.lock.Lock()
+		if p.clients == nil {
+			p.clients = make(map[int]*rpc.Client)
+		}
+		p.clients[to] = client
+		p.lock.Unlock()
+	}
+	err := client.Call(method,args,reply)
+	if _,failed := err.(rpc.ServerError); err != nil && !failed {
+		client.Close()
+		p.lock.Lock()
+		if p.clients[to] == client {
+			delete(p.clients,to)
+		}
+		p.lock.Unlock()
+	}
+	return err
+}
+
+/*The transport over TCP, with TLS when it has a TLS config. A node listens on its port no plus the offset.*/
+type tcptransport struct{
+	rpcpool
+	config *tls.Config
+	offset int
+}
+
+func (t *tcptransport) Listen(portno int) (net.Listener, error) {
+	listener, err := net.Listen("tcp",":"+strconv.Itoa(portno+t.offset))
+	if err != nil {
+		return nil,err
+	}
+	if t.config != nil {
+		listener = tls.NewListener(listener,t.config)
+	}
+	return listener,nil
+}
+
+func (t *tcptransport) Dial(from int, to int) (net.Conn, error) {
+	address := net.JoinHostPort(serverconfig.IpAddress,strconv.Itoa(to+t.offset))
+	dialer := &net.Dialer{Timeout: 5*time.Second}
+	if t.config == nil {
+		return dialer.Dial("tcp",address)
+	}
+	config := t.config.Clone()
+	config.ServerName = serverconfig.IpAddress
+	return tls.DialWithDialer(dialer,"tcp",address,config)
+}
+
+func (t *tcptransport) Call(from int, to int, method string, args interface{}, reply interface{}) error {
+	return t.call(t,from,to,method,args,reply)
+}
+
+/*The transport within the process, the connections are in-memory pipes.*/
+type memorytransport struct{
+	rpcpool
+	lock sync.Mutex
+	listeners map[int]*memorylistener
+}
+
+/*The listener of a node on the in-memory transport. It closes the connections it accepted when it is closed, as when the
+*node fails. The close map of the nodes hides the close function, so the listener is stopped by cancelling its context.*/
+type memorylistener struct{
+	transport *memorytransport
+	portno int
+	conns chan net.Conn
+	done <-chan struct{}
+	stop context.CancelFunc
+	lock sync.Mutex
+	accepted []net.Conn
+	closed bool
+}
+
+/*The address of a node on the in-memory transport.*/
+type memoryaddr int
+
+func (a memoryaddr) Network() string {
+	return "memory"
+}
+
+func (a memoryaddr) String() string {
+	return "memory:"+strconv.Itoa(int(a))
+}
+
+func newmemorytransport() *memorytransport {
+	return &memorytransport{listeners: make(map[int]*memorylistener)}
+}
+
+func (t *memorytransport) Listen(portno int) (net.Listener, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if _,ok := t.listeners[portno]; ok {
+		return nil,errors.New("Transport error - The node "+strconv.Itoa(portno)+" is already listening")
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	listener := &memorylistener{transport: t, portno: portno, conns: make(chan net.Conn,16), done: ctx.Done(), stop: stop}
+	t.listeners[portno] = listener
+	return listener,nil
+}
+
+func (t *memorytransport) Dial(from int, to int) (net.Conn, error) {
+	t.lock.Lock()
+	listener, ok := t.listeners[to]
+	t.lock.Unlock()
+	if !ok {
+		return nil,errors.New("Transport error - The node "+strconv.Itoa(to)+" is not listening")
+	}
+	client, server := net.Pipe()
+	select {
+	case listener.conns <- server:
+		return client,nil
+	case <-listener.done:
+		return nil,errors.New("Transport error - The node "+strconv.Itoa(to)+" is not listening")
+	}
+}
+
+func (t *memorytransport) Call(from int, to int, method string, args interface{}, reply interface{}) error {
+	return t.call(t,from,to,method,args,reply)
+}
+
+func (l *memorylistener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		l.lock.Lock()
+		defer l.lock.Unlock()
+		if l.closed {
+			conn.Close()
+			return nil,net.ErrClosed
+		}
+		l.accepted = append(l.accepted,conn)
+		return conn,nil
+	case <-l.done:
+		return nil,net.ErrClosed
+	}
+}
+
+func (l *memorylistener) Close() error {
+	l.transport.lock.Lock()
+	if l.transport.listeners[l.portno] == l {
+		delete(l.transport.listeners,l.portno)
+	}
+	l.transport.lock.Unlock()
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	l.stop()
+	for _,conn := range l.accepted {
+		conn.Close()
+	}
+	return nil
+}
+
+func (l *memorylistener) Addr() net.Addr {
+	return memoryaddr(l.portno)
+}
+
+/*The transport that injects faults into the calls of another transport, for testing: every call is delayed by the latency, is
+*lost with the probability of the loss and fails when the nodes are in different groups of the partition. The nodes that are
+*in no group are in a group of their own.*/
+type faultytransport struct{
+	Transport
+	latency time.Duration
+	loss float64
+	lock sync.Mutex
+	rand *mathrand.Rand
+	groups map[int]int
+}
+
+func newfaultytransport(inner Transport, latency time.Duration, loss float64, seed int64) *faultytransport {
+	return &faultytransport{Transport: inner, latency: latency, loss: loss, rand: mathrand.New(mathrand.NewSource(seed)), groups: make(map[int]int)}
+}
+
+/*This function is used to split the nodes into groups that cannot call each other, no groups heal the partition.
+*input: The port nos of the nodes of every group.*/
+func (t *faultytransport) Partition(groups [][]int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.groups = make(map[int]int)
+	for i,group := range groups {
+		for _,port := range group {
+			t.groups[port] = i+1
+		}
+	}
+}
+
+/*This function is used to check whether a node can reach another node and whether a call between them is lost.
+*input: The port nos of the two nodes.
+*output: The error if the call fails.*/
+func (t *faultytransport) fault(from int, to int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.groups[from] != t.groups[to] {
+		return errors.New("Transport error - The node "+strconv.Itoa(to)+" cannot be reached from "+strconv.Itoa(from))
+	}
+	if t.loss > 0 && t.rand.Float64() < t.loss {
+		return errors.New("Transport error - The call from "+strconv.Itoa(from)+" to "+strconv.Itoa(to)+" was lost")
+	}
+	return nil
+}
+
+func (t *faultytransport) Dial(from int, to int) (net.Conn, error) {
+	if err := t.fault(from,to); err != nil {
+		return nil,err
+	}
+	return t.Transport.Dial(from,to)
+}
+
+func (t *faultytransport) Call(from int, to int, method string, args interface{}, reply interface{}) error {
+	time.Sleep(t.latency)
+	if err := t.fault(from,to); err != nil {
+		return err
+	}
+	return t.Transport.Call(from,to,method,args,reply)
+}
+
+/*This function is used to set up the transports from the config, TCP with the TLS config of the clients for the clients and
+*the transport of the transport config for the nodes, wrapped to inject the faults of the config if it has any.
+*output: The error if the transport config is invalid.*/
+func inittransport() error {
+	clienttransport = &tcptransport{config: clienttls}
+	config := serverconfig.Transport
+	kind := config.Kind
+	if len(kind) == 0 {
+		kind = "memory"
+		if nodetls != nil {
+			kind = "tcp"
+		}
+	}
+	switch kind {
+	case "memory":
+		if nodetls != nil {
+			return errors.New("Config error - The nodetls config needs the tcp transport, the nodes only call each other over mutual TLS")
+		}
+		nodetransport = newmemorytransport()
+	case "tcp":
+		if nodetls == nil {
+			return errors.New("Config error - The tcp transport needs the nodetls config, the nodes only call each other over mutual TLS")
+		}
+		nodetransport = &tcptransport{config: nodetls, offset: serverconfig.NodeTLS.PortOffset}
+	default:
+		return errors.New("Config error - Invalid transport "+strconv.Quote(kind)+", use memory or tcp")
+	}
+	if config.Loss < 0 || config.Loss >= 1 || config.Latency < 0 {
+		return errors.New("Config error - The loss of the transport must be between 0 and 1 and its latency cannot be negative")
+	}
+	if config.Latency > 0 || config.Loss > 0 || len(config.Partitions) > 0 {
+		faulty := newfaultytransport(nodetransport,time.Duration(config.Latency)*time.Millisecond,config.Loss,time.Now().UnixNano())
+		faulty.Partition(config.Partitions)
+		nodetransport = faulty
+	}
+	return nil
+}
+
+/*This function is used to check the links of the ring by letting every node ping its successor over the transport of the nodes.
+*It is given a copy of the successor links since the pinged nodes need the ring lock to answer.
+*input: The successor of every node.
+*output: The number of links checked and the errors of the links that failed.*/
+func checknodelinks(successors map[int]int) (int, []error) {
+	failed := []error{}
+	checked := 0
+	for port,successor := range successors {
+		if successor == 0 || successor == port {
+			continue
+		}
+		checked++
+		var info NodeInfo
+		if err := callnode(port,successor,"Node.Ping",&JsonMessage{Portno: port},&info); err != nil {
+			failed = append(failed,errors.New(strconv.Itoa(port)+" -> "+strconv.Itoa(successor)+": "+err.Error()))
+		} else if info.Port != successor {
+			failed = append(failed,errors.New(strconv.Itoa(port)+" -> "+strconv.Itoa(successor)+": answered by "+strconv.Itoa(info.Port)))
+		}
+	}
+	return checked,failed
+}
+
+/*This contains a function that is used to add the server to the chord ring.
+*input: This input refers to the number of servers to add to the ring.
+*output: The error if a server cannot be started or the data cannot be handed to it. The servers started before it stay in the ring.*/
+func newServerInstance(n int) error {
+	ports := []int{}
+	var err error
+	for i := 1;i <= n && err == nil;i++ {
+		if err = startnode(use_ports); err == nil {
+			ports = append(ports,use_ports)
+			count_of_server++
+			use_ports++
+		}
+	}
+	if len(ports) == 0 {
+		return err
+	}
+	done, joinerr := joinring(ports)
+	if done {
+		logger.Info("the ring has stabilized","nodes",len(ringmap))
+	}
+	if len(ringmap) > 1 {
+		successors := make(map[int]int)
+		for port,server := range servermap {
+			successors[port] = server.successor
+		}
+		go func() {
+			checked, failed := checknodelinks(successors)
+			logger.Info("checked the links between the nodes","links",checked,"failed",len(failed))
+			for _,err := range failed {
+				logger.Error("a link between the nodes failed","error",err)
+			}
+		}()
+	}
+	if joinerr != nil {
+		return joinerr
+	}
+	return err
+}
+
+/*This function is used to start a node: its storage is opened, the entries it stored before are recovered and it starts to
+*accept connections. The node is not part of the ring until it joins it.
+*input: The port no of the node.
+*output: The error if the storage cannot be opened or the node cannot listen, nothing is left open then.*/
+func startnode(portno int) error {
+	log := nodelog(portno)
+	store, data, tombstones, err := openstore(portno)
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		log.Info("recovered the entries of the server","entries",len(data),"dir",store.dir)
+	}
+	changes, err := openchangelog(portno)
+	if err != nil {
+		store.wal.Close()
+		return err
+	}
+	close[portno] = true
+	err = startserver(portno)
+	if err == nil {
+		err = startnodeserver(portno)
+	}
+	if err != nil {
+		close[portno] = false
+		store.wal.Close()
+		changes.file.Close()
+		return err
+	}
+	stores[portno] = store
+	changelogs[portno] = changes
+	servermap[portno] = newnode(portno,data,tombstones)
+	if !serverconfig.Metrics.Disabled {
+		startmetrics(portno)
+	}
+	go sweeper(portno)
+	return nil
+}
+
+/*This function is used to create a node that is not part of the ring yet.
+*input: The port no of the node, the triplets it stores and the tombstones of its deleted triplets.
+*output: The node.*/
+func newnode(portno int, data map[datakey]datavalue, tombstones map[datakey]tombstone) Server {
+	delete(quotacounts,portno)
+	for k,v := range data {
+		countquota(portno,k,v,1)
+	}
+	return Server{portno,0,0,make(map[int]int),data,make(map[string][]walentry),make(map[datakey]string),time.Now(),time.Time{},false,nil,make(map[int]time.Time),tombstones}
+}
+
+/*This function is used to add nodes to the Chord ring. The ring is stabilized and every new node takes the triplets it owns from
+*its successor over the transport of the nodes.
+*input: The port nos of the nodes, which have been added to the server map.
+*output: Whether the ring was stabilized and the error if a triplet could not be handed over.*/
+func joinring(ports []int) (bool, error) {
+	id := bootstrapring()
+	for _,port := range ports {
+		ringids[port] = id
+		AddtoRing(servermap[port])
+	}
+	//A single node is stabilized as well, it is its own successor and predecessor.
+	done := StabilizeRing()
+	if len(ringmap) > 1 {
+		for _,port := range ports {
+			succport := servermap[port].successor
+			var records NodeRecords
+			if err := ringcall(port,succport,"Node.Records",&JsonMessage{Portno: port},&records); err != nil {
+				return done,err
+			}
+			moved := make(map[int][]DICT3record)
+			handed := NodeRecords{Op: "move"}
+			for _,r := range records.Records {
+				targetport := FindSuccessor(DataHash(r.Key,r.Relationship),port)
+				dupver = dupver[:0]
+				if targetport >= 0 && targetport != succport {
+					moved[targetport] = append(moved[targetport],r)
+					handed.Records = append(handed.Records,r)
+				}
+			}
+			for targetport,records := range moved {
+				if err := ringcall(port,targetport,"Node.Put",&NodeRecords{records,"move"},&NoOutput{}); err != nil {
+					return done,err
+				}
+			}
+			if len(handed.Records) > 0 {
+				if err := ringcall(port,succport,"Node.Delete",&handed,&NoOutput{}); err != nil {
+					return done,err
+				}
+			}
+		}
+	}
+	markhandedoff(ports)
+	return done,nil
+}
+
+/*This function is used to return the ring new nodes join, the ring of the node of the config file or, when that node has left,
+*the ring of the first node.
+*output: The id of the ring.*/
+func bootstrapring() int {
+	if _,ok := servermap[serverconfig.Port]; ok {
+		return ringids[serverconfig.Port]
+	}
+	first := -1
+	for port := range ringids {
+		if first < 0 || port < first {
+			first = port
+		}
+	}
+	return ringids[first]
+}
+
+/*This function is used to let a node leave the Chord ring. Its triplets are handed to its successor over the transport of the
+*nodes, its storage is removed, its successor remembers the nodes it remembered and the nodes that remembered it remember its
+*successor instead, so that rings that have split still know a node of each other, and the ring is stabilized. The successor is looked up so that the triplets go to the next live node when the successor has failed
+*and the ring has not noticed it yet. The last node of the ring does not leave it.
+*input: The port no of the node.
+*output: Whether the node left and the error if a triplet could not be handed over.*/
+func leavering(portno int) (bool, error) {
+	succport := FindSuccessor((ringposition(portno)+1)%ringsize,portno)
+	dupver = dupver[:0]
+	if succport == portno || succport < 0 {
+		return false,nil
+	}
+	records := NodeRecords{Op: "move"}
+	for id,value := range servermap[portno].data {
+		records.Records = append(records.Records,torecord(id,value))
+	}
+	for id,t := range servermap[portno].tombstones {
+		records.Records = append(records.Records,tombstonerecord(id,t))
+	}
+	if len(records.Records) > 0 {
+		if err := ringcall(portno,succport,"Node.Put",&records,&NoOutput{}); err != nil {
+			return false,err
+		}
+	}
+	removestore(portno)
+	members := servermap[portno].members
+	removenode(portno)
+	for member,seen := range members {
+		if last,known := servermap[succport].members[member]; member != succport && (!known || last.Before(seen)) {
+			servermap[succport].members[member] = seen
+		}
+	}
+	for _,server := range servermap {
+		if seen,ok := server.members[portno]; ok {
+			delete(server.members,portno)
+			if last,known := server.members[succport]; server.portno != succport && (!known || last.Before(seen)) {
+				server.members[succport] = seen
+			}
+		}
+	}
+	StabilizeRing()
+	return true,nil
+}
+
+/*This function is used to take a node out of the server map and the ring without handing over its triplets or stabilizing the
+*ring, as when the node fails. The node stops listening for the other nodes and its metrics server is closed.
+*input: The port no of the node.*/
+func removenode(portno int) {
+	stopnodeserver(portno)
+	stopmetrics(portno)
+	delete(quotacounts,portno)
+	delete(servermap,portno)
+	delete(ringids,portno)
+	for k,v := range ringmap {
+		if v == portno {
+			delete(ringmap,k)
+		}
+	}
+}
+
+/*This function is used to return the node a ping, health or ready request is about, the first param or the node of the client.
+*input: The input of the request.
+*output: The port no of the node.*/
+func nodeparam(input *JsonMessage) int {
+	if port, ok := intparam(input,0); ok {
+		return int(port)
+	}
+	return input.Portno
+}
+
+/*This function is used to check the health of a node. The successor and the predecessor are alive if they are in the ring and
+*answer a ping over the transport of the nodes, which is sent without the ring lock.
+*input: The port no of the node.
+*output: The health of the node.*/
+func nodehealth(portno int) NodeHealth {
+	ringlock.Lock()
+	server, ok := servermap[portno]
+	health := NodeHealth{NodePing: NodePing{Port: portno, ID: -1}}
+	if ok {
+		now := time.Now()
+		health.ID = ringposition(portno)
+		health.Uptime = now.Sub(server.started).Seconds()
+		for _,v := range server.data {
+			if !expired(v,now) {
+				health.Keys++
+			}
+		}
+		if !server.stabilized.IsZero() {
+			health.LastStabilized = server.stabilized.Format(time.RFC3339Nano)
+		}
+		health.HandoffComplete = server.handedoff
+		health.Healthy = close[portno]
+		health.Successor = PeerHealth{Port: server.successor, ID: -1}
+		health.Predecessor = PeerHealth{Port: server.predecessor, ID: -1}
+		for _,peer := range []*PeerHealth{&health.Successor,&health.Predecessor} {
+			if _,in := servermap[peer.Port]; in && close[peer.Port] {
+				peer.ID = ringposition(peer.Port)
+				peer.Alive = true
+			}
+		}
+	}
+	ringlock.Unlock()
+	for _,peer := range []*PeerHealth{&health.Successor,&health.Predecessor} {
+		if peer.Port == 0 {
+			peer.Error = "no link"
+		} else if !peer.Alive {
+			peer.Error = "the node is not in the ring"
+		} else if peer.Port != portno {
+			var info NodeInfo
+			if err := callnode(portno,peer.Port,"Node.Ping",&JsonMessage{Portno: portno},&info); err != nil {
+				peer.Alive = false
+				peer.Error = err.Error()
+			} else if info.Port != peer.Port {
+				peer.Alive = false
+				peer.Error = "answered by "+strconv.Itoa(info.Port)
+			}
+		}
+	}
+	switch {
+	case !ok:
+		health.Status = "the node is not in the ring"
+	case !health.Healthy:
+		health.Status = "the node does not accept connections"
+	case len(health.LastStabilized) == 0:
+		health.Status = "the node has not stabilized"
+	case !health.HandoffComplete:
+		health.Status = "the keys have not been handed to the node"
+	case !health.Successor.Alive:
+		health.Status = "the successor is not alive: "+health.Successor.Error
+	case !health.Predecessor.Alive:
+		health.Status = "the predecessor is not alive: "+health.Predecessor.Error
+	default:
+		health.Status = "ok"
+		health.Ready = true
+	}
+	return health
+}
+
+/*This function is used to mark the nodes that joined the ring as having the keys they own handed to them.
+*input: The port nos of the nodes.*/
+func markhandedoff(ports []int) {
+	for _,port := range ports {
+		if server, ok := servermap[port]; ok {
+			server.handedoff = true
+			servermap[port] = server
+		}
+	}
+}
+
+/*This function is used to find the partitions of the ring. Every node probes the nodes it has seen and the nodes of its ring,
+*the nodes that answer are remembered and those that have not answered for the forget time are forgotten. The nodes that reach
+*each other form a ring: when some of the nodes of a ring cannot reach the others the ring is split into separate rings that
+*are stabilized on their own, and when the nodes of separate rings reach each other again the rings are merged and their
+*triplets are reconciled. The caller must hold the ring lock, which is released while the nodes are probed.
+*output: Whether rings have been merged and the error if the triplets of merged rings could not be reconciled.*/
+func detectpartitions() (bool, error) {
+	probes := probetargets()
+	ringlock.Unlock()
+	probenodes(probes)
+	ringlock.Lock()
+	return applyprobes(probes)
+}
+
+/*This structure is used to describe the probe of a node by another node.
+*port, member: The port nos of the probing and of the probed node.
+*reached: Whether the probed node has answered.
+*at: The time of the probe.*/
+type probe struct{
+	port int
+	member int
+	reached bool
+	at time.Time
+}
+
+/*This function is used to list the probes of a round: every node probes the nodes it has seen and the nodes of its ring. The
+*caller holds the ring lock.
+*output: The probes in the order of the probing and of the probed node.*/
+func probetargets() []probe {
+	ports := []int{}
+	for port := range servermap {
+		ports = append(ports,port)
+	}
+	sort.Ints(ports)
+	views := ringviews()
+	probes := []probe{}
+	for _,port := range ports {
+		server := servermap[port]
+		targets := []int{}
+		for member := range server.members {
+			targets = append(targets,member)
+		}
+		for _,member := range views[ringids[port]] {
+			if _,ok := server.members[member]; !ok && member != port {
+				targets = append(targets,member)
+			}
+		}
+		sort.Ints(targets)
+		for _,member := range targets {
+			probes = append(probes,probe{port: port, member: member})
+		}
+	}
+	return probes
+}
+
+/*This function is used to probe the nodes over the transport of the nodes. It is called without the ring lock, which the probed
+*nodes take to answer.
+*input: The probes, whose results are filled in.*/
+func probenodes(probes []probe) {
+	for i := range probes {
+		probes[i].reached = callnode(probes[i].port,probes[i].member,"Node.Routes",&JsonMessage{Portno: probes[i].port},&NodeRoutes{}) == nil
+		probes[i].at = time.Now()
+	}
+}
+
+/*This function is used to apply the results of the probes to the ring, as described for detectpartitions. The nodes that have
+*left the ring while they were probed are left out. The caller holds the ring lock.
+*input: The probes with their results.
+*output: Whether rings have been merged and the error if the triplets of merged rings could not be reconciled.*/
+func applyprobes(probes []probe) (bool, error) {
+	ports := []int{}
+	for port := range servermap {
+		ports = append(ports,port)
+	}
+	sort.Ints(ports)
+	parent := make(map[int]int)
+	for _,port := range ports {
+		parent[port] = port
+	}
+	find := func(port int) int {
+		for parent[port] != port {
+			port = parent[port]
+		}
+		return port
+	}
+	forget := time.Duration(serverconfig.Partition.Forget)*time.Second
+	for _,p := range probes {
+		server, ok := servermap[p.port]
+		if !ok {
+			continue
+		}
+		if !p.reached {
+			if seen,ok := server.members[p.member]; ok && p.at.Sub(seen) > forget {
+				delete(server.members,p.member)
+				nodelog(p.port).Info("forgot a node that does not answer","node",p.member)
+			}
+			continue
+		}
+		server.members[p.member] = p.at
+		if _,ok := servermap[p.member]; ok {
+			parent[find(p.member)] = find(p.port)
+		}
+	}
+
+	//The nodes that reach each other keep the id of their ring, a ring that is split keeps its id in the part with the first node
+	components := make(map[int][]int)
+	roots := []int{}
+	for _,port := range ports {
+		root := find(port)
+		if _,ok := components[root]; !ok {
+			roots = append(roots,root)
+		}
+		components[root] = append(components[root],port)
+	}
+	claimed := make(map[int]bool)
+	assigned := make(map[int]int)
+	merged := [][]int{}
+	for _,root := range roots {
+		rings := make(map[int]bool)
+		id := -1
+		for _,port := range components[root] {
+			rings[ringids[port]] = true
+			if id < 0 || ringids[port] < id {
+				id = ringids[port]
+			}
+		}
+		if claimed[id] {
+			nextringid++
+			id = nextringid
+		}
+		claimed[id] = true
+		for _,port := range components[root] {
+			assigned[port] = id
+		}
+		if len(rings) > 1 {
+			merged = append(merged,components[root])
+		}
+	}
+	changed := false
+	for _,port := range ports {
+		if ringids[port] != assigned[port] {
+			ringids[port] = assigned[port]
+			changed = true
+		}
+	}
+	//A merge that could not hand every triplet to its owner is tried again with the nodes that are still there.
+	for _,component := range unmerged {
+		alive := []int{}
+		for _,port := range component {
+			if _,ok := servermap[port]; ok {
+				alive = append(alive,port)
+			}
+		}
+		if len(alive) > 1 {
+			merged = append(merged,alive)
+		}
+	}
+	unmerged = nil
+	if !changed && len(merged) == 0 {
+		return false,nil
+	}
+	if changed {
+		StabilizeRing()
+	}
+	if len(roots) > 1 {
+		logger.Warn("the ring is partitioned","rings",len(roots))
+	}
+	var failed error
+	for _,component := range merged {
+		conflicts, err := mergerings(component)
+		if err != nil {
+			unmerged = append(unmerged,component)
+			if failed == nil {
+				failed = err
+			}
+			continue
+		}
+		logger.Info("merged the rings of the reachable nodes","nodes",len(component),"conflicts",conflicts)
+	}
+	return len(merged) > 0,failed
+}
+
+/*This function is used to reconcile the triplets of rings that have been merged. Every key is kept once, on its owner in the
+*merged ring, and when the rings have stored different values for a key the newest one is kept. The tombstones take part as well,
+*so a key deleted in one of the rings while they were apart stays deleted unless it has been changed in another ring since. The
+*keys that cannot be handed to their owner are left on their nodes, so that nothing is lost and the merge can be tried again.
+*input: The port nos of the nodes of the merged ring.
+*output: The number of keys with conflicting values and the error if the triplets could not be moved.*/
+func mergerings(ports []int) (int, error) {
+	from := ports[0]
+	copies := make(map[datakey]map[int]datavalue)
+	graves := make(map[datakey]map[int]tombstone)
+	keys := []datakey{}
+	for _,port := range ports {
+		var records NodeRecords
+		if err := ringcall(from,port,"Node.Records",&JsonMessage{Portno: from},&records); err != nil {
+			return 0,err
+		}
+		for _,r := range records.Records {
+			k := datakey{r.Key,r.Relationship}
+			if _,ok := copies[k]; !ok {
+				copies[k], graves[k] = make(map[int]datavalue), make(map[int]tombstone)
+				keys = append(keys,k)
+			}
+			if len(r.Deleted) != 0 {
+				_, t := fromtombstone(r)
+				graves[k][port] = t
+				continue
+			}
+			_, v := fromrecord(r)
+			copies[k][port] = v
+		}
+	}
+	sort.Slice(keys,func(i, j int) bool {
+		return keys[i].key < keys[j].key || (keys[i].key == keys[j].key && keys[i].relation < keys[j].relation)
+	})
+	conflicts := 0
+	puts := make(map[int][]DICT3record)
+	owners := make(map[datakey]int)
+	removed := make(map[datakey]bool)
+	for _,k := range keys {
+		var winner datavalue
+		first := true
+		for _,v := range copies[k] {
+			if first || newer(v,winner) {
+				winner, first = v, false
+			}
+		}
+		var grave tombstone
+		dead := false
+		for _,t := range graves[k] {
+			if !dead || t.version > grave.version {
+				grave, dead = t, true
+			}
+		}
+		deleted := dead && (first || buried(grave,winner))
+		conflict := deleted && !first
+		for _,v := range copies[k] {
+			if v.content != winner.content || v.version != winner.version {
+				conflict = true
+			}
+		}
+		if conflict {
+			conflicts++
+			logger.Warn("resolved a conflict of the merged rings","key",k.key,"relationship",k.relation,"version",winner.version,"copies",len(copies[k]),"deleted",deleted)
+		}
+		owner := FindSuccessor(DataHash(k.key,k.relation),from)
+		dupver = dupver[:0]
+		if owner < 0 {
+			return conflicts,errors.New("Partition error - The owner of the key "+k.key+" cannot be reached")
+		}
+		owners[k], removed[k] = owner, deleted
+		v, live := copies[k][owner]
+		if deleted {
+			if t,ok := graves[k][owner]; live || !ok || t.version < grave.version {
+				puts[owner] = append(puts[owner],tombstonerecord(k,grave))
+			}
+		} else if !live || newer(winner,v) {
+			puts[owner] = append(puts[owner],torecord(k,winner))
+		}
+	}
+	var failed error
+	unmoved := make(map[datakey]bool)
+	for port,records := range puts {
+		if err := ringcall(from,port,"Node.Put",&NodeRecords{records,"merge"},&NoOutput{}); err != nil {
+			nodelog(port).Error("the merged triplets could not be handed to their owner, they are kept on their nodes","triplets",len(records),"error",err)
+			for _,r := range records {
+				unmoved[datakey{r.Key,r.Relationship}] = true
+			}
+			if failed == nil {
+				failed = err
+			}
+		}
+	}
+	//The copies of a key that is kept have been handed to its owner, while the copies of a key that is deleted are removed.
+	deletes := map[string]map[int][]DICT3record{"move": {}, "merge": {}}
+	for _,k := range keys {
+		if unmoved[k] {
+			continue
+		}
+		op := "move"
+		if removed[k] {
+			op = "merge"
+		}
+		for _,port := range ports {
+			_, live := copies[k][port]
+			_, dead := graves[k][port]
+			if port != owners[k] && (live || dead) {
+				deletes[op][port] = append(deletes[op][port],DICT3record{Key: k.key, Relationship: k.relation})
+			}
+		}
+	}
+	for op,batches := range deletes {
+		for port,records := range batches {
+			if err := ringcall(from,port,"Node.Delete",&NodeRecords{records,op},&NoOutput{}); err != nil && failed == nil {
+				failed = err
+			}
+		}
+	}
+	return conflicts,failed
+}
+
+/*This function is used to decide which of two values of a triplet is newer: the one with the higher version, then the one
+*written last and then the greater value, so that every node decides the same.
+*input: The two values.
+*output: Whether the first value is newer.*/
+func newer(a datavalue, b datavalue) bool {
+	if a.version != b.version {
+		return a.version > b.version
+	}
+	if !written(a).Equal(written(b)) {
+		return written(a).After(written(b))
+	}
+	return a.content > b.content
+}
+
+/*This function is used to decide whether a tombstone is newer than a value of its triplet: the one with the higher version, then
+*a deletion after the value was written.
+*input: The tombstone and the value.
+*output: Whether the tombstone is newer.*/
+func buried(t tombstone, v datavalue) bool {
+	if t.version != v.version {
+		return t.version > v.version
+	}
+	return t.deleted.After(written(v))
+}
+
+/*This function is used to return the time a value of a triplet was written.
+*input: The value.
+*output: The time it was modified, or created when it was never modified.*/
+func written(v datavalue) time.Time {
+	if v.modified.IsZero() {
+		return v.created
+	}
+	return v.modified
+}
+
+/*This function runs in the background and looks for partitions of the ring and for partitions that have healed after every probe interval.
+*The nodes are probed without the ring lock, which is only taken to apply the results.*/
+func prober() {
+	ticker := time.NewTicker(time.Duration(serverconfig.Partition.ProbeInterval)*time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		ringlock.Lock()
+		probes := probetargets()
+		ringlock.Unlock()
+		probenodes(probes)
+		unlock := lockmembers()
+		if _,err := applyprobes(probes); err != nil {
+			logger.Error("the merged rings could not be reconciled","error",err)
+		}
+		unlock()
+	}
+}
+
+/*This is the main function that is used to get the input from the user.
+*It is used to display the list of active servers and also to add a new server to the chord ring if needed.*/
+func main(){
+	var nodes int
+	var choice int
+	var port int
+	servermap = make(map[int]Server)
+	stores = make(map[int]*nodestore)
+	dict3 := new(Dict3)
+	rpc.Register(dict3)
+	count = 0
+	count_of_server = 0
+	if len(os.Args) != 2 && (len(os.Args) < 3 || (os.Args[2] != "audit" && os.Args[2] != "collector")) {
+		fmt.Println("Usage: ", os.Args[0], "Enter config Json file path")
+		fmt.Println("       ", os.Args[0], "<config Json file path> audit [op=... client=... node=... owner=... key=... relationship=... result=ok|error since=... until=... limit=...]")
+		fmt.Println("       ", os.Args[0], "<config Json file path> collector [address, default :4318] [trace file, default stdout]")
+		log.Fatal(1)
+	}
+
+	inFile, err := os.Open(os.Args[1])
+	if err != nil {
+		checkError(err)
+	}
+
+	//Unmarshalling the server config JSON file.
+	scanner := bufio.NewScanner(inFile)
+	for scanner.Scan() {
+		err := json.Unmarshal([]byte(scanner.Text()),&serverconfig)
+		if err != nil {
+			checkError(err)
+		}
+	}
+	use_ports = serverconfig.Port
+	checkError(initlogging())
+	timediff = time.Duration(serverconfig.DeleteTimeOut) * time.Second
+	if serverconfig.SweepInterval <= 0 {
+		serverconfig.SweepInterval = 5
+	}
+	if len(serverconfig.Storage.Dir) == 0 {
+		serverconfig.Storage.Dir = "data"
+	}
+	if serverconfig.Storage.SnapshotInterval <= 0 {
+		serverconfig.Storage.SnapshotInterval = 60
+	}
+	if len(serverconfig.Storage.ArchiveDir) == 0 {
+		serverconfig.Storage.ArchiveDir = "archives"
+	}
+	if serverconfig.CDC.Retention <= 0 {
+		serverconfig.CDC.Retention = 10000
+	}
+	if len(serverconfig.Audit.File) == 0 {
+		serverconfig.Audit.File = filepath.Join(serverconfig.Storage.Dir,"audit.log")
+	}
+	if serverconfig.Audit.MaxSize <= 0 {
+		serverconfig.Audit.MaxSize = 10*1024*1024
+	}
+	if serverconfig.Audit.Backups <= 0 {
+		serverconfig.Audit.Backups = 5
+	}
+	if serverconfig.Auth.MaxSkew <= 0 {
+		serverconfig.Auth.MaxSkew = 300
+	}
+	for _,user := range serverconfig.Auth.Users {
+		if len(user.User) == 0 || len(user.Secret) == 0 || roleranks[user.Role] == 0 {
+			checkError(errors.New("every user of the auth config needs a user, a secret and the role read, write or admin"))
+		}
+	}
+	if serverconfig.Auth.Disabled && len(serverconfig.Auth.Users) != 0 {
+		checkError(errors.New("the auth config cannot have users and be disabled"))
+	}
+	if serverconfig.NodeTLS.PortOffset <= 0 {
+		serverconfig.NodeTLS.PortOffset = 1000
+	}
+	if serverconfig.Metrics.PortOffset <= 0 {
+		serverconfig.Metrics.PortOffset = 2000
+	}
+	if serverconfig.Tracing.Sample <= 0 {
+		serverconfig.Tracing.Sample = 1
+	}
+	if serverconfig.Partition.ProbeInterval == 0 {
+		serverconfig.Partition.ProbeInterval = 5
+	}
+	if serverconfig.Partition.Forget <= 0 {
+		serverconfig.Partition.Forget = 600
+	}
+	if len(os.Args) > 2 && os.Args[2] == "audit" {
+		checkError(QueryAudit(os.Args[3:],os.Stdout))
+		return
+	}
+	if len(os.Args) > 2 && os.Args[2] == "collector" {
+		address, filename := ":4318", ""
+		if len(os.Args) > 3 {
+			address = os.Args[3]
+		}
+		if len(os.Args) > 4 {
+			filename = os.Args[4]
+		}
+		checkError(RunCollector(address,filename))
+		return
+	}
+	if len(serverconfig.Tracing.URL) != 0 {
+		traces = make(chan []byte,1000)
+		go tracesender()
+	}
+	checkError(inittls())
+	checkError(inittransport())
+	checkError(initmethods())
+	checkError(initlimits())
+	InitializeRing()
+	checkError(newServerInstance(1))
+	fmt.Println("Enter the number of nodes to start the system")
+	fmt.Scanf("%d",&nodes)
+	unlock := lockmembers()
+	checkError(newServerInstance(nodes))
+	if moved := VerifyRing(serverconfig.Port,true).Repaired; moved > 0 {
+		logger.Info("moved recovered triplets to their owner","triplets",moved)
+	}
+	if id, err := recovertransaction(); err != nil {
+		logger.Error("the interrupted transaction could not be completed","error",err)
+	} else if len(id) != 0 {
+		logger.Info("completed the commit of the interrupted transaction","transaction",id)
+	}
+	file := serverconfig.PersistentStorageContainer.File
+	if report, err := LoadDICT3(file,serverconfig.Port); err != nil {
+		logger.Error("the DICT3 file could not be loaded","file",file,"error",err)
+	} else {
+		logger.Info("loaded the DICT3 file","file",file,"loaded",report.Loaded,"skipped",report.Skipped,"rejected",len(report.Rejected))
+		for _,row := range report.Rejected {
+			logger.Warn("rejected a line of the DICT3 file","file",file,"line",row.Line,"reason",row.Reason)
+		}
+	}
+	unlock()
+	go snapshotter()
+	if serverconfig.Partition.ProbeInterval > 0 {
+		go prober()
+	}
+	for true {
+		fmt.Println();
+		fmt.Println("1. Add a node to the system")
+		fmt.Println("2. List all currently running servers")
+		fmt.Println("3. Display the data present in the server.")
+		fmt.Println("4. Display Position in Chord Ring, Successor Node, Predecessor Node and Finger Table for a server")
+		fmt.Println("5. Exit")
+		fmt.Println("6. Export the topology of the Chord ring (JSON or Graphviz DOT)")
+		fmt.Println("Enter the choice:")
+		fmt.Scanf("%d",&choice)
+		switch choice {
+				case 1: unlock := lockmembers()
+								if err := newServerInstance(1); err != nil {
+									logger.Error("the node could not be added","error",err)
+								}
+								unlock()
+				case 2: fmt.Println();
+								fmt.Println("The list of currently running servers with their Port Nos are as below-")
+								for k,_ := range servermap {
+									fmt.Println(k)
+								}
+				case 3: fmt.Println("Enter the port number of the server to check the data.")
+								fmt.Scanf("%d",&port)
+								ringlock.Lock()
+								for k,v := range servermap{
+									if k == port {
+										if len(v.data) > 0{
+											fmt.Println();
+											fmt.Println("The data present in the server is as below-");
+											for key,val := range v.data {
+												fmt.Print(key.key,"\t",key.relation)
+												fmt.Print("\t",val.content,"\t",val.size,"B\t",formattimestamp(val.created),"\t",formattimestamp(val.modified),"\t",formattimestamp(val.accessed),"\t",val.permission,"\tv",val.version)
+												fmt.Println();
+											}
+										}else{
+											fmt.Println("The server does not currently have any stored data.");
+										}
+									break
+									}
+								}
+								ringlock.Unlock()
+				case 4: fmt.Println("Enter the port number of the server.")
+								fmt.Scanf("%d",&port)
+								ringlock.Lock()
+								for k,v := range servermap{
+									if k == port {
+										pos := ringposition(k)
+										fmt.Println("Position in Chord Ring - ",pos)
+										fmt.Println("Successor Node - ",v.successor)
+										fmt.Println("Predecessor Node- ",v.predecessor)
+										fmt.Println("Finger Table - ")
+										for _,finger := range sortedfingers(pos,v.fingertable) {
+											fmt.Println(finger.Start,"\t",finger.Node)
+										}
+										break
+									}
+								}
+								ringlock.Unlock()
+				case 5:	var ch string
+								fmt.Println("Do you want to save the data stored in the server? 'y' or 'n'")
+								fmt.Scanf("%s",&ch)
+								ch = strings.ToLower(ch)
+								ringlock.Lock()
+								status := 0
+								if err := closestores(); err != nil {
+									logger.Error("the storage could not be closed","error",err)
+									status = 1
+								}
+								if(ch == "y"){
+									if err := saveDICT3(); err != nil {
+										logger.Error("the DICT3 file could not be saved","file",serverconfig.PersistentStorageContainer.File,"error",err)
+										status = 1
+									}
+								}
+								os.Exit(status)
+				case 6: var format, filename string
+								fmt.Println("Enter the format of the export. 'json' or 'dot'")
+								fmt.Scanf("%s",&format)
+								fmt.Println("Enter the name of the file to export the topology to.")
+								fmt.Scanf("%s",&filename)
+								ringlock.Lock()
+								topology := BuildTopology()
+								ringlock.Unlock()
+								var out []byte
+								if strings.ToLower(format) == "dot" {
+									out = []byte(TopologyDOT(topology))
+								} else {
+									out, _ = json.MarshalIndent(topology,"","  ")
+									out = append(out,'\n')
+								}
+								if err := os.WriteFile(filename,out,0660); err != nil {
+									fmt.Println("The topology could not be exported - ",err)
+								} else {
+									fmt.Println("The topology of the ring has been exported to",filename)
+								}
+				default: fmt.Println("The entered choice is invalid")
+		}
+	}
+	var input string
+	fmt.Scanln(&input)
+}
+
+
+/*This function is used to set up the log of the server from the log config.
+*output: The error if the level, the output or the format is invalid.*/
+func initlogging() error {
+	var level slog.Level
+	if len(serverconfig.Log.Level) != 0 {
+		if err := level.UnmarshalText([]byte(serverconfig.Log.Level)); err != nil {
+			return errors.New("Config error - Invalid log level "+strconv.Quote(serverconfig.Log.Level))
+		}
+	}
+	var out io.Writer = os.Stderr
+	switch serverconfig.Log.Output {
+	case "", "stderr":
+	case "stdout":
+		out = os.Stdout
+	default:
+		file, err := os.OpenFile(serverconfig.Log.Output,os.O_WRONLY|os.O_APPEND|os.O_CREATE,0660)
+		if err != nil {
+			return errors.New("Config error - The log file could not be opened: "+err.Error())
+		}
+		out = file
+	}
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(serverconfig.Log.Format) {
+	case "", "text":
+		logger = slog.New(slog.NewTextHandler(out,options))
+	case "json":
+		logger = slog.New(slog.NewJSONHandler(out,options))
+	default:
+		return errors.New("Config error - Invalid log format "+strconv.Quote(serverconfig.Log.Format)+", use text or json")
+	}
+	return nil
+}
+
+/*This function is used to return the log of a node, every line of which has the port no of the node.
+*input: The port no of the node.
+*output: The log of the node.*/
+func nodelog(portno int) *slog.Logger {
+	return logger.With("node",portno)
+}
+
+/*This function is used to start the trace of a request when tracing is enabled. The request joins the trace of the client if its
+*traceparent is valid and the client sampled it, otherwise a new trace is started for the configured fraction of the requests.
+*input: The request, the name of the function, the port no of the node and the time the request was read.
+*output: The trace of the request or nil if the request is not traced.*/
+func starttrace(input *JsonMessage, method string, portno int, start time.Time) *trace {
+	if len(serverconfig.Tracing.File) == 0 && traces == nil {
+		return nil
+	}
+	traceid, parent, sampled := parsetraceparent(input.Traceparent)
+	if len(traceid) == 0 {
+		traceid = newid(16)
+		sampled = sampletrace(traceid)
+	}
+	if !sampled {
+		return nil
+	}
+	t := &trace{id: traceid}
+	t.root = t.newspan(parent,method,2,portno,start)
+	t.root.Attributes = append(t.root.Attributes,stringattr("client.address",input.Client),stringattr("dict3.request",input.RequestID))
+	if len(input.User) != 0 {
+		t.root.Attributes = append(t.root.Attributes,stringattr("dict3.user",input.User))
+	}
+	return t
+}
+
+/*This function is used to parse a W3C traceparent, 00-<trace id>-<parent span id>-<flags>.
+*input: The traceparent.
+*output: The trace id, the parent span id and whether the trace is sampled, or empty ids if the traceparent is invalid.*/
+func parsetraceparent(traceparent string) (string, string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent),"-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return "", "", false
+	}
+	for _,part := range parts {
+		if strings.Trim(part,"0123456789abcdef") != "" {
+			return "", "", false
+		}
+	}
+	if strings.Trim(parts[1],"0") == "" || strings.Trim(parts[2],"0") == "" {
+		return "", "", false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return parts[1], parts[2], flags[0]&1 == 1
+}
+
+/*This function is used to decide if a new trace is sampled. The decision depends only on the trace id.
+*input: The trace id.
+*output: Whether the trace is sampled.*/
+func sampletrace(traceid string) bool {
+	if serverconfig.Tracing.Sample >= 1 {
+		return true
+	}
+	v, _ := strconv.ParseUint(traceid[16:],16,64)
+	return float64(v) < serverconfig.Tracing.Sample*float64(math.MaxUint64)
+}
+
+/*This function is used to create a random trace or span id.
+*input: The length of the id in bytes.
+*output: The id in hex.*/
+func newid(n int) string {
+	b := make([]byte,n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+/*These functions are used to create the attributes of a span.*/
+func stringattr(key string, value string) SpanAttribute {
+	return SpanAttribute{key,SpanValue{StringValue: &value}}
+}
+
+func intattr(key string, value int) SpanAttribute {
+	v := strconv.Itoa(value)
+	return SpanAttribute{key,SpanValue{IntValue: &v}}
+}
+
+/*This function is used to add a span to a trace.
+*input: The id of the parent span, the name and the kind of the span, the port no of the node it runs on and its start.
+*output: The span.*/
+func (t *trace) newspan(parent string, name string, kind int, portno int, start time.Time) *Span {
+	s := &Span{TraceID: t.id, SpanID: newid(8), ParentSpanID: parent, Name: name, Kind: kind, start: start}
+	s.Attributes = []SpanAttribute{intattr("dict3.node",portno)}
+	t.spans = append(t.spans,s)
+	return s
+}
+
+/*This function is used to find the successor of a key for a traced request. The lookup is a span of the request, and every node
+*the lookup asks for its routing table adds its own span of the call to the trace. Without a trace it is the same as FindSuccessor.
+*input: The key and the port no of the node the lookup starts from.
+*output: The successor of the key.*/
+func (t *trace) findsuccessor(key int, portno int) int {
+	if t == nil {
+		return FindSuccessor(key,portno)
+	}
+	s := t.newspan(t.root.SpanID,"chord.find_successor",1,portno,time.Now())
+	successor, hops := lookup(key,portno,"00-"+t.id+"-"+s.SpanID+"-01")
+	s.Attributes = append(s.Attributes,intattr("chord.key",key),intattr("chord.successor",successor),intattr("chord.hops",hops))
+	s.end = time.Now()
+	return successor
+}
+
+/*This function is used by a node that is called by another node to start its span of a traced request. The span is a child of
+*the span of the calling node in the traceparent of the call and is exported by the called node when the call ends.
+*input: The input of the call, the name of the span and the port no of the called node.
+*output: The function that ends and exports the span, which does nothing when the call is not traced.*/
+func nodespan(input *JsonMessage, name string, portno int) func() {
+	if len(serverconfig.Tracing.File) == 0 && traces == nil {
+		return func() {}
+	}
+	traceid, parent, sampled := parsetraceparent(input.Traceparent)
+	if !sampled {
+		return func() {}
+	}
+	t := &trace{id: traceid}
+	t.root = t.newspan(parent,name,2,portno,time.Now())
+	t.root.Attributes = append(t.root.Attributes,intattr("chord.caller",input.Portno))
+	return func() {
+		exporttrace(t,"")
+	}
+}
+
+/*This function is used to start a span of a traced request, e.g. the storage operation on the node that owns a key.
+*input: The name of the span and the port no of the node it runs on.
+*output: The function that ends the span, which does nothing without a trace.*/
+func (t *trace) span(name string, portno int) func() {
+	if t == nil {
+		return func() {}
+	}
+	s := t.newspan(t.root.SpanID,name,1,portno,time.Now())
+	return func() {
+		s.end = time.Now()
+	}
+}
+
+/*This function is used to end the trace of a request and export it to the trace file and to the collector. The spans that were
+*not ended end with the request. A trace that cannot be exported is dropped with a warning.
+*input: The trace and the error of the request.*/
+func exporttrace(t *trace, errmsg string) {
+	now := time.Now()
+	t.root.end = now
+	t.root.Status = SpanStatus{Code: 1}
+	if len(errmsg) != 0 {
+		t.root.Status = SpanStatus{2,errmsg}
+	}
+	for _,s := range t.spans {
+		if s.end.IsZero() {
+			s.end = now
+		}
+		s.StartTimeUnixNano = strconv.FormatInt(s.start.UnixNano(),10)
+		s.EndTimeUnixNano = strconv.FormatInt(s.end.UnixNano(),10)
+	}
+	resource := TraceResource{[]SpanAttribute{stringattr("service.name","dict3")}}
+	data, err := json.Marshal(TraceExport{[]ResourceSpans{{resource,[]ScopeSpans{{TraceScope{"dict3"},t.spans}}}}})
+	if err != nil {
+		logger.Warn("trace dropped","trace",t.id,"error",err)
+		return
+	}
+	if len(serverconfig.Tracing.File) != 0 {
+		if err := appendtrace(data); err != nil {
+			logger.Warn("trace dropped","trace",t.id,"file",serverconfig.Tracing.File,"error",err)
+		}
+	}
+	if traces != nil {
+		select {
+		case traces <- data:
+		default:
+			logger.Warn("trace dropped","trace",t.id,"error","the queue of the collector is full")
+		}
+	}
+}
+
+/*This function is used to append a trace to the trace file as a line.
+*input: The trace in JSON.
+*output: The error if the trace could not be written.*/
+func appendtrace(data []byte) error {
+	tracelock.Lock()
+	defer tracelock.Unlock()
+	file, err := os.OpenFile(serverconfig.Tracing.File,os.O_WRONLY|os.O_CREATE|os.O_APPEND,0660)
+	if err != nil {
+		return err
+	}
+	if _,err := file.Write(append(data,'\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+/*This function posts the traces in the queue to the collector one at a time, so that the requests do not wait for the collector.*/
+func tracesender() {
+	client := &http.Client{Timeout: 5*time.Second}
+	for data := range traces {
+		resp, err := client.Post(serverconfig.Tracing.URL,"application/json",bytes.NewReader(data))
+		if err != nil {
+			logger.Warn("trace export failed","url",serverconfig.Tracing.URL,"error",err)
+			continue
+		}
+		io.Copy(io.Discard,resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			logger.Warn("trace export failed","url",serverconfig.Tracing.URL,"status",resp.Status)
+		}
+	}
+}
+
+/*This function is used to run a minimal trace collector from the command line for when no OpenTelemetry collector is at hand.
+*It accepts the traces posted to /v1/traces in the OTLP JSON format and appends every trace to the file as a line.
+*input: The address to listen on and the file, the standard output if it is empty.
+*output: The error if the collector could not listen or the file could not be opened.*/
+func RunCollector(address string, filename string) error {
+	var out io.Writer = os.Stdout
+	if len(filename) != 0 {
+		file, err := os.OpenFile(filename,os.O_WRONLY|os.O_CREATE|os.O_APPEND,0660)
+		if err != nil {
+			return errors.New("Collector error - The trace file could not be opened: "+err.Error())
+		}
+		defer file.Close()
+		out = file
+	}
+	var lock sync.Mutex
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/traces", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w,"Only POST is allowed",http.StatusMethodNotAllowed)
+			return
+		}
+		var export TraceExport
+		var line bytes.Buffer
+		data, err := io.ReadAll(io.LimitReader(r.Body,16<<20))
+		if err == nil {
+			err = json.Unmarshal(data,&export)
+		}
+		if err == nil {
+			err = json.Compact(&line,data)
+		}
+		if err != nil {
+			http.Error(w,"Invalid trace: "+err.Error(),http.StatusBadRequest)
+			return
+		}
+		line.WriteByte('\n')
+		lock.Lock()
+		out.Write(line.Bytes())
+		lock.Unlock()
+		w.Header().Set("Content-Type","application/json")
+		w.Write([]byte("{}"))
+	})
+	logger.Info("collector listening","address",address)
+	return http.ListenAndServe(address,mux)
+}
+
+/* This function is used to check for any errors returned by the function. It is only used where the server cannot continue,
+* while starting up, and logs the error and exits.
+* error: It refers to the error value that needs to be checked.*/
+func checkError(err error) {
+	if err != nil {
+		logger.Error("fatal error","error",err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*This function is used to configure the users of a test, one for every role.*/
+func testusers(t *testing.T) {
+	t.Helper()
+	serverconfig = config{}
+	serverconfig.Auth.Users = []AuthUser{{"reader","read secret","read"},{"writer","write secret","write"},{"admin","admin secret","admin"}}
+	serverconfig.Auth.MaxSkew = 300
+	nonces = make(map[string]time.Time)
+}
+
+/*The number of the last nonce of a signed request of the tests.*/
+var testnonce int
+
+/*This function is used to sign a request for a test the way the client does.
+*input: The function, the input, the user and the secret it is signed with and the time of the request.*/
+func testsign(method string, input *JsonMessage, user string, secret string, at time.Time) {
+	testnonce++
+	input.Auth = &AuthToken{User: user, Timestamp: at.Unix(), Nonce: "nonce"+strconv.Itoa(testnonce)}
+	input.Auth.Signature = signrequest(method,input,input.Auth,secret)
+}
+
+/*This function is used to check the error a request is rejected with.
+*input: The test, the error and the text it must contain, empty if the request must be accepted.*/
+func testrejected(t *testing.T, err error, reason string) {
+	t.Helper()
+	if len(reason) == 0 && err != nil {
+		t.Fatal("the request was rejected:",err)
+	} else if len(reason) != 0 && (err == nil || !strings.Contains(err.Error(),reason)) {
+		t.Fatal("the request was not rejected with",strconv.Quote(reason)+":",err)
+	}
+}
+
+/*This test checks that every user can only call the functions of its role and that only an admin can use the override flag.*/
+func TestAuthRoles(t *testing.T) {
+	testusers(t)
+	cases := []struct{
+		method string
+		user string
+		override bool
+		reason string
+	}{
+		{"Dict3.LookUp","reader",false,""},
+		{"Dict3.Insert","reader",false,"is not allowed to call Insert"},
+		{"Dict3.Insert","writer",false,""},
+		{"Dict3.Delete","writer",true,"needs the role \"admin\""},
+		{"Dict3.Delete","admin",true,""},
+		{"Dict3.Shutdown","writer",false,"is not allowed to call Shutdown"},
+		{"Dict3.Shutdown","admin",false,""},
+		{"Dict3.Unknown","writer",false,"needs the role \"admin\""},
+	}
+	for _,c := range cases {
+		input := &JsonMessage{Params: []interface{}{"key","rel","value"}, Override: c.override}
+		for _,u := range serverconfig.Auth.Users {
+			if u.User == c.user {
+				testsign(c.method,input,u.User,u.Secret,time.Now())
+			}
+		}
+		testrejected(t,authenticate(c.method,input),c.reason)
+		if len(c.reason) == 0 && input.User != c.user {
+			t.Fatal("the request of",c.user,"was taken as the request of",input.User)
+		}
+	}
+}
+
+/*This test checks that a request is rejected when it is not signed, signed with the wrong secret or changed after it was signed.*/
+func TestAuthSignature(t *testing.T) {
+	testusers(t)
+	input := &JsonMessage{Params: []interface{}{"key","rel"}}
+	testrejected(t,authenticate("Dict3.LookUp",input),"not authenticated")
+
+	testsign("Dict3.LookUp",input,"reader","write secret",time.Now())
+	testrejected(t,authenticate("Dict3.LookUp",input),"Invalid user or signature")
+
+	testsign("Dict3.LookUp",input,"nobody","read secret",time.Now())
+	testrejected(t,authenticate("Dict3.LookUp",input),"Invalid user or signature")
+
+	testsign("Dict3.LookUp",input,"reader","read secret",time.Now())
+	input.Params = []interface{}{"other","rel"}
+	testrejected(t,authenticate("Dict3.LookUp",input),"Invalid user or signature")
+
+	testsign("Dict3.LookUp",input,"reader","read secret",time.Now())
+	testrejected(t,authenticate("Dict3.ListKeys",input),"Invalid user or signature")
+}
+
+/*This test checks that a signed request cannot be replayed and that a request whose timestamp is too far from the clock of the
+*server is rejected.*/
+func TestAuthReplay(t *testing.T) {
+	testusers(t)
+	input := &JsonMessage{Params: []interface{}{"key","rel"}}
+	testsign("Dict3.LookUp",input,"reader","read secret",time.Now())
+	testrejected(t,authenticate("Dict3.LookUp",input),"")
+	testrejected(t,authenticate("Dict3.LookUp",input),"has already been received")
+
+	for _,skew := range []time.Duration{-400*time.Second,400*time.Second} {
+		testsign("Dict3.LookUp",input,"reader","read secret",time.Now().Add(skew))
+		testrejected(t,authenticate("Dict3.LookUp",input),"has expired")
+	}
+	for _,skew := range []time.Duration{-200*time.Second,200*time.Second} {
+		testsign("Dict3.LookUp",input,"reader","read secret",time.Now().Add(skew))
+		testrejected(t,authenticate("Dict3.LookUp",input),"")
+	}
+}
+
+/*This test checks that without users only the functions of the read role can be called, unless the authentication is disabled.*/
+func TestAuthWithoutUsers(t *testing.T) {
+	serverconfig = config{}
+	testrejected(t,authenticate("Dict3.LookUp",&JsonMessage{}),"")
+	testrejected(t,authenticate("Dict3.Insert",&JsonMessage{}),"No users are configured")
+	testrejected(t,authenticate("Dict3.LookUp",&JsonMessage{Override: true}),"No users are configured")
+	serverconfig.Auth.Disabled = true
+	testrejected(t,authenticate("Dict3.Insert",&JsonMessage{Override: true}),"")
+}
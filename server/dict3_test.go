@@ -0,0 +1,365 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"github.com/drakesh/CHORD-with-JSON-RPC/tabfile"
+)
+
+/*This function is used to start a ring of nodes for a test. The nodes call each other over the in-memory transport and keep their
+*storage in a temporary directory of the test, the authentication is disabled and the state of earlier tests is dropped.
+*input: The test and the number of nodes.
+*output: The port nos of the nodes.*/
+func testring(t *testing.T, nodes int) []int {
+	t.Helper()
+	for port := range nodelisteners {
+		stopnodeserver(port)
+	}
+	dir := t.TempDir()
+	serverconfig = config{}
+	serverconfig.Storage.Dir = filepath.Join(dir,"storage")
+	serverconfig.Storage.ArchiveDir = filepath.Join(dir,"archives")
+	serverconfig.Auth.Disabled = true
+	serverconfig.Partition.Forget = 600
+	logger = slog.New(slog.NewTextHandler(io.Discard,nil))
+	t.Cleanup(func() {
+		logger = slog.Default()
+	})
+	servermap = make(map[int]Server)
+	stores = make(map[int]*nodestore)
+	changelogs = make(map[int]*changelog)
+	quotacounts = make(map[int]map[quotacounter]quotacount)
+	watches = make(map[string]*watch)
+	ringids = make(map[int]int)
+	nextringid = 0
+	unmerged = nil
+	nodetransport = newmemorytransport()
+	InitializeRing()
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	ports := []int{}
+	for port := 7000; port < 7000+nodes; port++ {
+		store, data, tombstones, err := openstore(port)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stores[port] = store
+		servermap[port] = newnode(port,data,tombstones)
+		if err := startnodeserver(port); err != nil {
+			t.Fatal(err)
+		}
+		ports = append(ports,port)
+	}
+	t.Cleanup(func() {
+		for _,port := range ports {
+			stopnodeserver(port)
+			if store, ok := stores[port]; ok {
+				store.wal.Close()
+			}
+		}
+	})
+	if _,err := joinring(ports); err != nil {
+		t.Fatal(err)
+	}
+	return ports
+}
+
+/*This function is used to look up the value of a triplet for a test.
+*input: The test, the key and the relationship.
+*output: The value and whether the triplet was found.*/
+func testlookup(t *testing.T, key string, relationship string) (string, bool) {
+	t.Helper()
+	var output JsonResultLookUp
+	if err := new(Dict3).LookUp(JsonMessage{Params: []interface{}{key,relationship}, Portno: 7000},&output); err != nil {
+		if strings.Contains(err.Error(),"not found") {
+			return "",false
+		}
+		t.Fatal(err)
+	}
+	for _,r := range output.Result {
+		if r[0] == key && r[1] == relationship {
+			return r[2],true
+		}
+	}
+	return "",false
+}
+
+/*This function is used to insert a triplet for a test.
+*input: The test, the key, the relationship and the value.
+*output: The version of the new triplet.*/
+func testinsert(t *testing.T, key string, relationship string, value string) int64 {
+	t.Helper()
+	var output JsonResultInsert
+	if err := new(Dict3).Insert(&JsonMessage{Params: []interface{}{key,relationship,value}, Portno: 7000},&output); err != nil {
+		t.Fatal(err)
+	}
+	return output.Version
+}
+
+/*This test checks that a restore replaces the data of the ring with the data of an archive and that a restore that cannot write
+*the new data of every node changes nothing.*/
+func TestRestore(t *testing.T) {
+	testring(t,4)
+	d := new(Dict3)
+	for _,key := range []string{"a","b","c"} {
+		testinsert(t,key,"rel","old "+key)
+	}
+	var archive JsonArchive
+	if err := d.Snapshot(&JsonMessage{Params: []interface{}{"before"}, Portno: 7000},&archive); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete(&JsonMessage{Params: []interface{}{"a","rel"}, Portno: 7000},&NoOutput{}); err != nil {
+		t.Fatal(err)
+	}
+	testinsert(t,"d","rel","new d")
+
+	owner := ringsuccessor(DataHash("b","rel"))
+	dir := stores[owner].dir
+	stores[owner].dir = filepath.Join(dir,"missing")
+	if err := d.Restore(&JsonMessage{Params: []interface{}{"before"}, Portno: 7000},&archive); err == nil {
+		t.Fatal("the restore succeeded without the storage of a node")
+	}
+	stores[owner].dir = dir
+	if _,ok := testlookup(t,"a","rel"); ok {
+		t.Fatal("a failed restore brought back a deleted triplet")
+	}
+	if value,ok := testlookup(t,"d","rel"); !ok || value != "new d" {
+		t.Fatal("a failed restore changed the ring:",value)
+	}
+
+	if err := d.Restore(&JsonMessage{Params: []interface{}{"before"}, Portno: 7000},&archive); err != nil {
+		t.Fatal(err)
+	}
+	for _,key := range []string{"a","b","c"} {
+		if value,ok := testlookup(t,key,"rel"); !ok || value != "old "+key {
+			t.Fatal("the restore did not bring back",key,value)
+		}
+	}
+	if _,ok := testlookup(t,"d","rel"); ok {
+		t.Fatal("the restore kept a triplet that is not in the archive")
+	}
+	if _,err := os.Stat(filepath.Join(stores[owner].dir,"snapshot.json")); err != nil {
+		t.Fatal("the restored data of the node is not durable:",err)
+	}
+}
+
+/*This test checks that the repair of a misplaced triplet keeps the newer of the copy on the owner and the misplaced copy.*/
+func TestVerifyRepair(t *testing.T) {
+	ports := testring(t,4)
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	now := time.Now().UTC()
+	cases := []struct{
+		key string
+		owner int64
+		misplaced int64
+		kept string
+	}{
+		{"newer",1,2,"misplaced"},
+		{"older",3,2,"owner"},
+	}
+	for _,c := range cases {
+		k := datakey{c.key,"rel"}
+		owner := ringsuccessor(DataHash(k.key,k.relation))
+		other := ports[0]
+		if other == owner {
+			other = ports[1]
+		}
+		if err := putEntry(owner,k,datavalue{content: "owner", created: now, version: c.owner}); err != nil {
+			t.Fatal(err)
+		}
+		if err := putEntry(other,k,datavalue{content: "misplaced", created: now, version: c.misplaced}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if report := VerifyRing(ports[0],true); report.Repaired != len(cases) {
+		t.Fatal("repaired",report.Repaired,"of",len(cases),"misplaced triplets")
+	}
+	for _,c := range cases {
+		k := datakey{c.key,"rel"}
+		for port,server := range servermap {
+			v, ok := server.data[k]
+			if owner := ringsuccessor(DataHash(k.key,k.relation)); port != owner && ok {
+				t.Fatal("the misplaced copy of",c.key,"is still on",port)
+			} else if port == owner && v.content != c.kept {
+				t.Fatal("the owner kept",v.content,"for",c.key,"instead of",c.kept)
+			}
+		}
+	}
+}
+
+/*This test checks that an import rejects the triplets that are locked by a transaction or that exceed a quota.*/
+func TestImportChecks(t *testing.T) {
+	testring(t,3)
+	serverconfig.Limits.Quotas = []QuotaType{{Prefix: "q", Entries: 2}}
+	k := datakey{"locked","rel"}
+	owner := ringsuccessor(DataHash(k.key,k.relation))
+	servermap[owner].locks[k] = "tx"
+	records := []interface{}{}
+	for _,key := range []string{"q1","q2","q3","locked"} {
+		records = append(records,map[string]interface{}{"key": key, "relationship": "rel", "value": "v"})
+	}
+	var output JsonImport
+	if err := new(Dict3).Import(&JsonMessage{Params: []interface{}{records}, Portno: 7000},&output); err != nil {
+		t.Fatal(err)
+	}
+	if output.Result != 2 || len(output.Rejected) != 2 {
+		t.Fatal("imported",output.Result,"and rejected",output.Rejected)
+	}
+	if !strings.Contains(output.Rejected[0].Reason,"Quota error") || !strings.Contains(output.Rejected[1].Reason,"locked") {
+		t.Fatal("rejected for the wrong reasons:",output.Rejected)
+	}
+	if _,ok := servermap[owner].data[k]; ok {
+		t.Fatal("the import overwrote a locked triplet")
+	}
+}
+
+/*This test checks that the DICT3 file keeps the ttl, the version and the creator of the triplets and that a load rejects the
+*triplets that are locked by a transaction.*/
+func TestDICT3File(t *testing.T) {
+	testring(t,3)
+	file := filepath.Join(t.TempDir(),"DICT3")
+	now := time.Now().UTC()
+	saved := map[datakey]datavalue{
+		{"tab\tkey","rel"}: {"line\none", 8, now, now, now, "RW", time.Hour, 5, "alice"},
+		{"locked","rel"}: {"value", 5, now, now, now, "R", 0, 2, "bob"},
+	}
+	ringlock.Lock()
+	for k,v := range saved {
+		if err := putEntry(ringsuccessor(DataHash(k.key,k.relation)),k,v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	serverconfig.PersistentStorageContainer.File = file
+	err := saveDICT3()
+	ringlock.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testring(t,3)
+	locked := datakey{"locked","rel"}
+	servermap[ringsuccessor(DataHash(locked.key,locked.relation))].locks[locked] = "tx"
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	report, err := LoadDICT3(file,7000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Loaded != 1 || len(report.Rejected) != 1 || !strings.Contains(report.Rejected[0].Reason,"locked") {
+		t.Fatal("loaded",report.Loaded,"and rejected",report.Rejected)
+	}
+	k := datakey{"tab\tkey","rel"}
+	v, ok := servermap[ringsuccessor(DataHash(k.key,k.relation))].data[k]
+	if !ok {
+		t.Fatal("the triplet was not loaded")
+	}
+	if v.content != saved[k].content || v.ttl != time.Hour || v.version != 5 || v.creator != "alice" {
+		t.Fatal("the triplet was not kept:",v.content,v.ttl,v.version,v.creator)
+	}
+}
+
+/*This function is used to call compareAndSwap for a test.
+*input: The key, the relationship, the expected version and the new value.
+*output: The new version of the triplet and the error if any.*/
+func testcas(key string, relationship string, expected int64, value string) (int64, error) {
+	var output JsonResultCAS
+	err := new(Dict3).CompareAndSwap(&JsonMessage{Params: []interface{}{key,relationship,float64(expected),value}, Portno: 7000},&output)
+	return output.Version,err
+}
+
+/*This function is used to call insertOrUpdate for a test.
+*input: The key, the relationship, the new value and the optional expected version.
+*output: The new version of the triplet and the error if any.*/
+func testupsert(key string, relationship string, value string, expected *int64) (int64, error) {
+	var output JsonResultInsert
+	err := new(Dict3).InsertOrUpdate(&JsonMessage{Params: []interface{}{key,relationship,value}, Portno: 7000, ExpectedVersion: expected},&output)
+	return output.Version,err
+}
+
+/*This test checks that compareAndSwap and insertOrUpdate only write a triplet that has the expected version, that an expected
+*version of zero means that the triplet must not exist and that the versions of a deleted triplet are continued.*/
+func TestCompareAndSwap(t *testing.T) {
+	testring(t,3)
+	if _,err := testcas("missing","rel",3,"v"); err == nil || !strings.Contains(err.Error(),"Conflict error") {
+		t.Fatal("a missing triplet was swapped:",err)
+	}
+	if _,ok := testlookup(t,"missing","rel"); ok {
+		t.Fatal("a failed swap inserted the triplet")
+	}
+	if version,err := testcas("k","rel",0,"one"); err != nil || version != 1 {
+		t.Fatal("the swap of a missing triplet did not insert it:",version,err)
+	}
+	if version,err := testcas("k","rel",1,"two"); err != nil || version != 2 {
+		t.Fatal("the swap with the stored version failed:",version,err)
+	}
+	for _,expected := range []int64{0,1,3} {
+		if _,err := testcas("k","rel",expected,"stale"); err == nil || !strings.Contains(err.Error(),"Conflict error") {
+			t.Fatal("the swap with the version",expected,"was not rejected:",err)
+		}
+	}
+	if value,_ := testlookup(t,"k","rel"); value != "two" {
+		t.Fatal("a rejected swap changed the triplet to",value)
+	}
+
+	stale := int64(1)
+	if _,err := testupsert("k","rel","stale",&stale); err == nil || !strings.Contains(err.Error(),"Conflict error") {
+		t.Fatal("the upsert with a stale version was not rejected:",err)
+	}
+	if version,err := testupsert("k","rel","three",nil); err != nil || version != 3 {
+		t.Fatal("the upsert without a version failed:",version,err)
+	}
+
+	if err := new(Dict3).Delete(&JsonMessage{Params: []interface{}{"k","rel"}, Portno: 7000},&NoOutput{}); err != nil {
+		t.Fatal(err)
+	}
+	if _,err := testcas("k","rel",3,"after"); err == nil || !strings.Contains(err.Error(),"Conflict error") {
+		t.Fatal("the swap of a deleted triplet with its last version was not rejected:",err)
+	}
+	version, err := testcas("k","rel",0,"four")
+	if err != nil || version <= 3 {
+		t.Fatal("the versions did not continue after the delete:",version,err)
+	}
+	if err := new(Dict3).Delete(&JsonMessage{Params: []interface{}{"k","rel"}, Portno: 7000},&NoOutput{}); err != nil {
+		t.Fatal(err)
+	}
+	if next,err := testupsert("k","rel","five",nil); err != nil || next <= version {
+		t.Fatal("the versions did not continue after the second delete:",next,"after",version,err)
+	}
+}
+
+/*This test checks that the fields of a DICT3 file are only unescaped when the file starts with the header, so that the backslashes
+*of the files written before the fields were escaped are kept, and that the raw tabs in their values are kept as well.*/
+func TestDICT3Header(t *testing.T) {
+	now := formattimestamp(time.Now())
+	meta := "\t5\t"+now+"\t\t"+now+"\tRW"
+	files := []struct{
+		content string
+		value string
+	}{
+		{"k\trel\tC:\\temp\\new"+meta+"\n","C:\\temp\\new"},
+		{"k\trel\tone\ttwo"+meta+"\n","one\ttwo"},
+		{tabfile.Header+"\nk\trel\tC:\\\\temp\\tnew"+meta+"\n","C:\\temp\tnew"},
+	}
+	for _,f := range files {
+		testring(t,3)
+		file := filepath.Join(t.TempDir(),"DICT3")
+		if err := os.WriteFile(file,[]byte(f.content),0660); err != nil {
+			t.Fatal(err)
+		}
+		ringlock.Lock()
+		report, err := LoadDICT3(file,7000)
+		ringlock.Unlock()
+		if err != nil || report.Loaded != 1 {
+			t.Fatal("the file was not loaded:",report,err)
+		}
+		if value,_ := testlookup(t,"k","rel"); value != f.value {
+			t.Fatalf("the value %q was loaded as %q",f.value,value)
+		}
+	}
+}
@@ -0,0 +1,596 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"math"
+	mathrand "math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+/*The seeds the simulations run, a failing run is repeated with -sim.seed=<seed> -sim.seeds=1 -sim.verbose.*/
+var simseed = flag.Int64("sim.seed",1,"the first seed of every simulation")
+var simseeds = flag.Int("sim.seeds",5,"the number of seeds every simulation runs")
+var simverbose = flag.Bool("sim.verbose",false,"whether every event of the simulations is logged")
+
+/*The options and the state of a simulation of the Chord ring. The nodes run in the process and call each other over the in-memory
+*transport and the time is virtual, so a run only depends on its seed.
+*Seed: The seed of the scheduler, a run is repeated by running it with the same seed.
+*Nodes, Steps, Keys: The nodes the ring starts with, the number of events of the workload and the number of keys it uses.
+*Drop, Delay: The probability that a message of a client is lost and the longest time a message takes to arrive.
+*Crashes, Detect: Whether nodes crash instead of some of the leaves and the time it takes for the ring to notice a crash.
+*Partition, Heal, Probe: The probability that a step splits the nodes into two groups that cannot reach each other, the time
+*until the partition heals and the time between the probes of the nodes that detect it.*/
+type simulation struct{
+	Seed int64
+	Nodes int
+	Steps int
+	Keys int
+	Drop float64
+	Delay time.Duration
+	Crashes bool
+	Detect time.Duration
+	Partition float64
+	Heal time.Duration
+	Probe time.Duration
+	t *testing.T
+	rand *mathrand.Rand
+	faulty *faultytransport
+	split bool
+	diverged bool
+	end time.Duration
+	now time.Duration
+	queue []simevent
+	seq int
+	nextport int
+	unstable int
+	model map[datakey]string
+	stats simstats
+}
+
+/*An event of a simulation. A client message is the insert, update, delete or lookup of a key sent to a node, which is resent after a
+*timeout when it is dropped.*/
+type simevent struct{
+	at time.Duration
+	seq int
+	kind string
+	port int
+	key datakey
+	value string
+	attempt int
+}
+
+/*The counters of a run of a simulation.*/
+type simstats struct{
+	writes int
+	rejected int
+	reads int
+	joins int
+	leaves int
+	crashes int
+	lost int
+	dropped int
+	failed int
+	misrouted int
+	maxhops int
+	verified int
+	checks int
+	partitions int
+	merges int
+}
+
+/*The number of times a client sends a message before it gives up and the time it waits for an answer.*/
+const simattempts = 3
+const simtimeout = 100*time.Millisecond
+
+/*The simulations of the ring. Every run injects joins, leaves and dropped and delayed client messages, and crashes and partitions
+*where the case has them, and fails when a key is lost, a lookup of a settled ring misses the owner or takes more hops than the
+*ring has fingers. The ring keeps no replicas, so a crash loses exactly the keys of the crashed node, which are taken out of the
+*model when the node crashes; any other key that goes missing fails the run. The invariants are checked after every event of a
+*settled ring and the lookups of every key from every node after every tenth step.*/
+func TestSimulation(t *testing.T) {
+	cases := []struct{
+		name string
+		sim simulation
+	}{
+		{"churn",simulation{Nodes: 8, Steps: 500, Keys: 50}},
+		{"lossy",simulation{Nodes: 8, Steps: 500, Keys: 50, Drop: 0.1, Delay: 50*time.Millisecond}},
+		{"crashes",simulation{Nodes: 16, Steps: 500, Keys: 50, Drop: 0.05, Delay: 20*time.Millisecond, Crashes: true, Detect: 200*time.Millisecond}},
+		{"partitions",simulation{Nodes: 16, Steps: 1000, Keys: 50, Drop: 0.05, Delay: 20*time.Millisecond, Partition: 0.01, Heal: time.Second, Probe: 100*time.Millisecond}},
+	}
+	serverconfig = config{}
+	serverconfig.Partition.Forget = 600
+	logger = slog.New(slog.NewTextHandler(io.Discard,nil))
+	defer func() {
+		logger = slog.Default()
+	}()
+	for _,c := range cases {
+		for seed := *simseed; seed < *simseed+int64(*simseeds); seed++ {
+			s := c.sim
+			s.Seed = seed
+			t.Run(c.name+"/seed="+strconv.FormatInt(seed,10),func(t *testing.T) {
+				s.t = t
+				if err := s.run(); err != nil {
+					t.Fatalf("at %s: %v",s.now,err)
+				}
+				t.Logf("%d nodes, %d writes (%d rejected), %d reads, %d joins, %d leaves, %d crashes (%d keys lost), %d partitions (%d merges), %d messages dropped, %d requests failed, %d lookups misrouted before settling, at most %d hops, %d verifications, %d lookup checks",
+					len(servermap),s.stats.writes,s.stats.rejected,s.stats.reads,s.stats.joins,s.stats.leaves,s.stats.crashes,s.stats.lost,s.stats.partitions,s.stats.merges,s.stats.dropped,s.stats.failed,s.stats.misrouted,s.stats.maxhops,s.stats.verified,s.stats.checks)
+			})
+		}
+	}
+}
+
+/*This function is used to run a simulation: the ring is started, the workload is scheduled and the events are run in the order
+*of their time until none are left. The invariants are verified after every event of a settled ring and the lookups checked after
+*every tenth step and at the end. A partition that is left at the end is healed and the rings are merged before the last check.
+*output: The error if an invariant is broken. The ring lock is held as by the functions of the server, the nodes take it to answer.*/
+func (s *simulation) run() error {
+	ringlock.Lock()
+	defer ringlock.Unlock()
+	s.rand = mathrand.New(mathrand.NewSource(s.Seed))
+	s.model = make(map[datakey]string)
+	s.nextport = 6000
+	for port := range nodelisteners {
+		stopnodeserver(port)
+	}
+	servermap = make(map[int]Server)
+	stores = make(map[int]*nodestore)
+	changelogs = make(map[int]*changelog)
+	quotacounts = make(map[int]map[quotacounter]quotacount)
+	watches = make(map[string]*watch)
+	ringids = make(map[int]int)
+	nextringid = 0
+	unmerged = nil
+	s.faulty = newfaultytransport(newmemorytransport(),0,0,s.Seed)
+	nodetransport = s.faulty
+	InitializeRing()
+	ports := []int{}
+	for i := 0; i < s.Nodes; i++ {
+		if err := s.startnode(s.nextport); err != nil {
+			return err
+		}
+		ports = append(ports,s.nextport)
+		s.nextport++
+	}
+	if _,err := joinring(ports); err != nil {
+		return err
+	}
+	for step := 1; step <= s.Steps; step++ {
+		at := time.Duration(step)*10*time.Millisecond
+		k := datakey{"key"+strconv.Itoa(s.rand.Intn(s.Keys)),"rel"}
+		switch r := s.rand.Float64(); {
+		case r < 0.2:
+			s.schedule(simevent{at: at, kind: "insert", key: k, value: "v"+strconv.Itoa(step)})
+		case r < 0.4:
+			s.schedule(simevent{at: at, kind: "update", key: k, value: "v"+strconv.Itoa(step)})
+		case r < 0.5:
+			s.schedule(simevent{at: at, kind: "delete", key: k})
+		case r < 0.8:
+			s.schedule(simevent{at: at, kind: "lookup", key: k})
+		case r < 0.9:
+			s.schedule(simevent{at: at, kind: "join"})
+		case r < 0.95 || !s.Crashes:
+			s.schedule(simevent{at: at, kind: "leave"})
+		default:
+			s.schedule(simevent{at: at, kind: "crash"})
+		}
+		if s.Partition > 0 && s.rand.Float64() < s.Partition {
+			s.schedule(simevent{at: at, kind: "partition"})
+		}
+		if step%10 == 0 {
+			s.schedule(simevent{at: at, kind: "check"})
+		}
+	}
+	s.end = time.Duration(s.Steps)*10*time.Millisecond
+	if s.Partition > 0 {
+		s.schedule(simevent{at: s.Probe, kind: "probe"})
+	}
+	for len(s.queue) > 0 {
+		e := s.queue[0]
+		s.queue = s.queue[1:]
+		s.now = e.at
+		if err := s.handle(e); err != nil {
+			return err
+		}
+		if s.settled() {
+			if err := s.verify(); err != nil {
+				return err
+			}
+		}
+	}
+	if s.split {
+		if err := s.handle(simevent{at: s.now, kind: "heal"}); err != nil {
+			return err
+		}
+	}
+	if s.Partition > 0 {
+		if err := s.probe(); err != nil {
+			return err
+		}
+	}
+	return s.check(true)
+}
+
+/*This function is used to tell whether the ring is settled, a single ring that has noticed every crash and whose values are
+*those of the model again after a partition, in which the lookups have to find the owner.
+*output: Whether the ring is settled.*/
+func (s *simulation) settled() bool {
+	return s.unstable == 0 && !s.split && !s.diverged && len(ringviews()) == 1
+}
+
+/*This function is used to run a probe of the nodes. When it merges rings it checks that every key stored on the nodes of a ring
+*before is kept in the ring with the newest of its values, or is deleted when its newest tombstone is newer than the value. While
+*the ring is split the rings change apart from the model, which is taken from the values of the nodes once they form a single ring again.
+*output: The error if the rings could not be merged or a key has been lost, kept with an older value or resurrected.*/
+func (s *simulation) probe() error {
+	before := make(map[int]map[datakey]datavalue)
+	graves := make(map[int]map[datakey]tombstone)
+	for port,server := range servermap {
+		before[port], graves[port] = make(map[datakey]datavalue), make(map[datakey]tombstone)
+		for k,v := range server.data {
+			before[port][k] = v
+		}
+		for k,t := range server.tombstones {
+			graves[port][k] = t
+		}
+	}
+	merged, err := detectpartitions()
+	if err != nil {
+		return err
+	}
+	after := make(map[datakey]string)
+	for _,server := range servermap {
+		for k,v := range server.data {
+			after[k] = v.content
+		}
+	}
+	if merged {
+		newest := make(map[int]map[datakey]datavalue)
+		deleted := make(map[int]map[datakey]tombstone)
+		kept := make(map[int]map[datakey]string)
+		for port,data := range before {
+			id := ringids[port]
+			if newest[id] == nil {
+				newest[id], deleted[id], kept[id] = make(map[datakey]datavalue), make(map[datakey]tombstone), make(map[datakey]string)
+			}
+			for k,v := range data {
+				if n,ok := newest[id][k]; !ok || newer(v,n) {
+					newest[id][k] = v
+				}
+			}
+			for k,t := range graves[port] {
+				if n,ok := deleted[id][k]; !ok || t.version > n.version {
+					deleted[id][k] = t
+				}
+			}
+			for k,v := range servermap[port].data {
+				kept[id][k] = v.content
+			}
+		}
+		for id := range newest {
+			for k,v := range newest[id] {
+				content, ok := kept[id][k]
+				if t,dead := deleted[id][k]; dead && buried(t,v) {
+					if ok {
+						return errors.New("the merge kept "+strconv.Quote(content)+" for the key "+k.key+" that was deleted")
+					}
+					continue
+				}
+				if !ok || content != v.content {
+					return errors.New("the merge kept "+strconv.Quote(content)+" for the key "+k.key+" instead of "+strconv.Quote(v.content))
+				}
+			}
+		}
+		s.stats.merges++
+		s.logf("merge into %d rings, %d keys",len(ringviews()),len(after))
+	}
+	if len(ringviews()) > 1 {
+		s.diverged = true
+	} else if s.diverged {
+		s.model = after
+		s.diverged = false
+	}
+	return nil
+}
+
+/*This function is used to start a node of a simulation, which listens on the in-memory transport and is not part of the ring yet.
+*input: The port no of the node.
+*output: The error if the node cannot listen.*/
+func (s *simulation) startnode(portno int) error {
+	servermap[portno] = newnode(portno,make(map[datakey]datavalue),make(map[datakey]tombstone))
+	return startnodeserver(portno)
+}
+
+/*This function is used to add an event to the queue, which is kept in the order of the time and then of the scheduling.
+*input: The event.*/
+func (s *simulation) schedule(e simevent) {
+	s.seq++
+	e.seq = s.seq
+	i := sort.Search(len(s.queue),func(i int) bool {
+		return s.queue[i].at > e.at || (s.queue[i].at == e.at && s.queue[i].seq > e.seq)
+	})
+	s.queue = append(s.queue,simevent{})
+	copy(s.queue[i+1:],s.queue[i:])
+	s.queue[i] = e
+}
+
+/*This function is used to log an event of a run when the simulations are verbose.
+*input: The format and the values of the line.*/
+func (s *simulation) logf(format string, values ...interface{}) {
+	if *simverbose {
+		s.t.Logf("%8s "+format,append([]interface{}{s.now},values...)...)
+	}
+}
+
+/*This function is used to return a random node of the ring, the nodes are sorted so that the choice only depends on the seed.
+*output: The port no of the node.*/
+func (s *simulation) randomnode() int {
+	ports := []int{}
+	for port := range servermap {
+		ports = append(ports,port)
+	}
+	sort.Ints(ports)
+	return ports[s.rand.Intn(len(ports))]
+}
+
+/*This function is used to run an event. A client message is sent to a random node and may be dropped, in which case it is sent
+*again after the timeout, or delayed. Joins and leaves change the ring at once, crashes are only noticed after the detect time.
+*input: The event.
+*output: The error if an invariant is broken.*/
+func (s *simulation) handle(e simevent) error {
+	switch e.kind {
+	case "insert", "update", "delete", "lookup":
+		if e.attempt == simattempts {
+			s.stats.failed++
+			s.logf("%s %s gave up after %d attempts",e.kind,e.key.key,e.attempt)
+			return nil
+		}
+		e.attempt++
+		if s.rand.Float64() < s.Drop {
+			s.stats.dropped++
+			s.logf("%s %s dropped",e.kind,e.key.key)
+			e.at = s.now+simtimeout
+			s.schedule(e)
+			return nil
+		}
+		e.kind, e.port = "deliver "+e.kind, s.randomnode()
+		e.at = s.now+time.Duration(s.rand.Int63n(int64(s.Delay)+1))
+		s.schedule(e)
+	case "deliver insert", "deliver update", "deliver delete", "deliver lookup":
+		return s.deliver(e)
+	case "join":
+		if len(servermap) >= 64 || s.split {
+			return nil
+		}
+		port := s.nextport
+		s.nextport++
+		if err := s.startnode(port); err != nil {
+			return err
+		}
+		if _,err := joinring([]int{port}); err != nil {
+			return err
+		}
+		s.stats.joins++
+		s.logf("join %d at %d",port,ringposition(port))
+	case "leave":
+		if len(servermap) < 2 {
+			return nil
+		}
+		port := s.randomnode()
+		left, err := leavering(port)
+		if err != nil || !left {
+			return err
+		}
+		s.stats.leaves++
+		s.logf("leave %d",port)
+	case "crash":
+		if len(servermap) < 2 {
+			return nil
+		}
+		port := s.randomnode()
+		lost := len(servermap[port].data)
+		for k := range servermap[port].data {
+			delete(s.model,k)
+		}
+		removenode(port)
+		s.stats.crashes++
+		s.stats.lost += lost
+		s.unstable++
+		s.logf("crash %d, %d keys lost",port,lost)
+		s.schedule(simevent{at: s.now+s.Detect, kind: "stabilize"})
+	case "stabilize":
+		StabilizeRing()
+		s.unstable--
+		s.logf("stabilize %d nodes",len(servermap))
+	case "partition":
+		if s.split || len(servermap) < 2 {
+			return nil
+		}
+		ports := []int{}
+		for port := range servermap {
+			ports = append(ports,port)
+		}
+		sort.Ints(ports)
+		s.rand.Shuffle(len(ports),func(i, j int) {
+			ports[i], ports[j] = ports[j], ports[i]
+		})
+		cut := 1+s.rand.Intn(len(ports)-1)
+		s.faulty.Partition([][]int{ports[:cut],ports[cut:]})
+		s.split = true
+		s.stats.partitions++
+		s.logf("partition %v from %v",ports[:cut],ports[cut:])
+		s.schedule(simevent{at: s.now+s.Heal, kind: "heal"})
+	case "heal":
+		s.faulty.Partition(nil)
+		s.split = false
+		s.logf("heal")
+	case "probe":
+		if err := s.probe(); err != nil {
+			return err
+		}
+		if s.now < s.end {
+			s.schedule(simevent{at: s.now+s.Probe, kind: "probe"})
+		}
+	case "check":
+		if s.settled() {
+			return s.check(false)
+		}
+	}
+	return nil
+}
+
+/*This function is used to deliver a client message to a node, which looks up the owner of the key and runs the request there.
+*Until a crash or a partition is noticed a lookup may miss the owner and the request fails so that the client sends it again, once
+*the ring is settled it has to find the owner in the ring of the node within as many hops as the ring has fingers. The request is
+*run by the functions of the server the clients call, which take the ring lock themselves, so it is released while they run. An
+*insert of a key that exists and a delete of a key that does not are rejected by the server and leave the model as it is.
+*input: The message.
+*output: The error if the lookup of a settled ring misses the owner or takes too many hops, a key has been lost or the server
+*fails a request for another reason.*/
+func (s *simulation) deliver(e simevent) error {
+	if _,ok := servermap[e.port]; !ok {
+		e.kind = strings.TrimPrefix(e.kind,"deliver ")
+		e.at = s.now+simtimeout
+		s.schedule(e)
+		return nil
+	}
+	hash := DataHash(e.key.key,e.key.relation)
+	owner := ringowner(hash,e.port)
+	target, hops := lookup(hash,e.port,"")
+	dupver = dupver[:0]
+	if hops > s.stats.maxhops {
+		s.stats.maxhops = hops
+	}
+	if s.settled() && hops > int(math.Log2(float64(ringsize))) {
+		return errors.New("the lookup of "+e.key.key+" from "+strconv.Itoa(e.port)+" took "+strconv.Itoa(hops)+" hops")
+	}
+	if target != owner {
+		if s.settled() {
+			return errors.New("the lookup of "+e.key.key+" from "+strconv.Itoa(e.port)+" found "+strconv.Itoa(target)+" instead of "+strconv.Itoa(owner))
+		}
+		s.stats.misrouted++
+		s.logf("%s %s misrouted to %d",e.kind,e.key.key,target)
+		e.kind = strings.TrimPrefix(e.kind,"deliver ")
+		e.at = s.now+simtimeout
+		s.schedule(e)
+		return nil
+	}
+	d := new(Dict3)
+	input := &JsonMessage{Params: []interface{}{e.key.key,e.key.relation,e.value}, Portno: e.port}
+	var err error
+	ringlock.Unlock()
+	switch e.kind {
+	case "deliver insert":
+		err = d.Insert(input,&JsonResultInsert{})
+	case "deliver update":
+		err = d.InsertOrUpdate(input,&JsonResultInsert{})
+	case "deliver delete":
+		err = d.Delete(input,&NoOutput{})
+	case "deliver lookup":
+		var output JsonResultLookUp
+		err = d.LookUp(*input,&output)
+		if err == nil && len(output.Result) == 1 {
+			e.value = output.Result[0][2]
+		}
+	}
+	ringlock.Lock()
+	rejected := err != nil && (strings.Contains(err.Error(),"already present") || strings.Contains(err.Error(),"not found"))
+	if err != nil && !rejected {
+		return errors.New("the "+strings.TrimPrefix(e.kind,"deliver ")+" of "+e.key.key+" on "+strconv.Itoa(target)+" failed: "+err.Error())
+	}
+	switch {
+	case e.kind == "deliver lookup":
+		want, ok := s.model[e.key]
+		if s.settled() && (rejected == ok || (ok && e.value != want)) {
+			return errors.New("the lookup of "+e.key.key+" on "+strconv.Itoa(target)+" found "+strconv.Quote(e.value)+" instead of "+strconv.Quote(want))
+		}
+		s.stats.reads++
+	case rejected:
+		s.stats.rejected++
+	case e.kind == "deliver delete":
+		delete(s.model,e.key)
+		s.stats.writes++
+	default:
+		s.model[e.key] = e.value
+		s.stats.writes++
+	}
+	s.logf("%s %s via %d on %d, %d hops",strings.TrimPrefix(e.kind,"deliver "),e.key.key,e.port,target,hops)
+	return nil
+}
+
+/*This function is used to verify the invariants of a settled ring that do not need lookups: the links and fingers of the nodes
+*agree with the ring, every key is stored on its owner only and no key has been lost.
+*output: The error of the first invariant that is broken.*/
+func (s *simulation) verify() error {
+	s.stats.verified++
+	report := VerifyRing(s.randomnode(),false)
+	switch {
+	case len(report.BrokenLinks) > 0:
+		return errors.New("broken link of "+strconv.Itoa(report.BrokenLinks[0].Node)+": "+report.BrokenLinks[0].Detail)
+	case len(report.IncorrectFingers) > 0:
+		return errors.New("incorrect finger of "+strconv.Itoa(report.IncorrectFingers[0].Node)+": "+report.IncorrectFingers[0].Detail)
+	case len(report.MisplacedKeys) > 0:
+		issue := report.MisplacedKeys[0]
+		return errors.New("the key "+issue.Key+" is stored on "+strconv.Itoa(issue.Node)+" instead of "+strconv.Itoa(issue.Owner))
+	}
+	stored := 0
+	for _,server := range servermap {
+		for k,v := range server.data {
+			stored++
+			if want, ok := s.model[k]; !ok || want != v.content {
+				return errors.New("the key "+k.key+" is stored with "+strconv.Quote(v.content)+" on "+strconv.Itoa(server.portno)+" but should be "+strconv.Quote(want))
+			}
+		}
+	}
+	if stored != len(s.model) {
+		return errors.New(strconv.Itoa(len(s.model)-stored)+" keys have been lost")
+	}
+	return nil
+}
+
+/*This function is used to check a settled ring: its invariants are verified and the lookups have to find the owner within as many
+*hops as the ring has fingers. Every key is looked up from a random node and a random key from every node, or every key from every
+*node.
+*input: Whether every key is looked up from every node.
+*output: The error of the first invariant that is broken.*/
+func (s *simulation) check(all bool) error {
+	if err := s.verify(); err != nil {
+		return err
+	}
+	s.stats.checks++
+	fingers := int(math.Log2(float64(ringsize)))
+	ports := []int{}
+	for port := range servermap {
+		ports = append(ports,port)
+	}
+	sort.Ints(ports)
+	full := ports[s.rand.Intn(len(ports))]
+	for _,port := range ports {
+		keys := []int{s.rand.Intn(s.Keys)}
+		if port == full || all {
+			keys = keys[:0]
+			for i := 0; i < s.Keys; i++ {
+				keys = append(keys,i)
+			}
+		}
+		for _,i := range keys {
+			hash := DataHash("key"+strconv.Itoa(i),"rel")
+			target, hops := lookup(hash,port,"")
+			if owner := ringsuccessor(hash); target != owner {
+				return errors.New("the lookup of key"+strconv.Itoa(i)+" from "+strconv.Itoa(port)+" found "+strconv.Itoa(target)+" instead of "+strconv.Itoa(owner))
+			}
+			if hops > fingers {
+				return errors.New("the lookup of key"+strconv.Itoa(i)+" from "+strconv.Itoa(port)+" took "+strconv.Itoa(hops)+" hops")
+			}
+		}
+	}
+	dupver = dupver[:0]
+	s.logf("check %d nodes, %d keys: ok",len(servermap),len(s.model))
+	return nil
+}
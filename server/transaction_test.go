@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+/*This function is used to find keys that are owned by different nodes, so that a transaction on them has more than one participant.
+*input: The test and the number of keys.
+*output: The keys, all with the relationship "rel".*/
+func testspread(t *testing.T, n int) []string {
+	t.Helper()
+	keys := []string{}
+	owners := make(map[int]bool)
+	for i := 0; i < 1000 && len(keys) < n; i++ {
+		key := "key"+strconv.Itoa(i)
+		if owner := ringsuccessor(DataHash(key,"rel")); !owners[owner] {
+			owners[owner] = true
+			keys = append(keys,key)
+		}
+	}
+	if len(keys) < n {
+		t.Fatal("no",n,"keys with different owners")
+	}
+	return keys
+}
+
+/*This function is used to check that no node holds a lock or a prepared change of a transaction and that the coordinator log is removed.
+*input: The test.*/
+func testreleased(t *testing.T) {
+	t.Helper()
+	for port,server := range servermap {
+		if len(server.locks) != 0 || len(server.prepared) != 0 {
+			t.Fatal("the node",port,"still holds",server.locks,"and",len(server.prepared),"prepared transactions")
+		}
+	}
+	if _,err := os.Stat(txlogpath()); !os.IsNotExist(err) {
+		t.Fatal("the coordinator log was not removed:",err)
+	}
+}
+
+/*This function is used to run a transaction for a test.
+*input: The operations.
+*output: The output of the transaction and the error if any.*/
+func testtransaction(ops []TxOp) (JsonTransaction, error) {
+	var output JsonTransaction
+	err := new(Dict3).Transaction(&JsonMessage{Params: []interface{}{ops}, Portno: 7000},&output)
+	return output,err
+}
+
+/*This test checks that a transaction is aborted on every node when one of its operations conflicts, and that the locks are released.*/
+func TestTransactionConflict(t *testing.T) {
+	testring(t,4)
+	keys := testspread(t,2)
+	first := testinsert(t,keys[0],"rel","first")
+	testinsert(t,keys[1],"rel","second")
+	wrong := int64(7)
+	_,err := testtransaction([]TxOp{
+		{Op: "put", Key: keys[0], Relationship: "rel", Value: "changed", ExpectedVersion: &first},
+		{Op: "put", Key: keys[1], Relationship: "rel", Value: "changed", ExpectedVersion: &wrong},
+	})
+	if err == nil || !strings.Contains(err.Error(),"Conflict error") {
+		t.Fatal("the conflicting transaction was not aborted:",err)
+	}
+	for i,value := range []string{"first","second"} {
+		if stored,_ := testlookup(t,keys[i],"rel"); stored != value {
+			t.Fatal("the aborted transaction changed",keys[i],"to",stored)
+		}
+	}
+	testreleased(t)
+}
+
+/*This test checks that a transaction releases its locks once it is committed, and that a triplet locked by another transaction
+*cannot be changed until that transaction is aborted.*/
+func TestTransactionLocks(t *testing.T) {
+	testring(t,4)
+	keys := testspread(t,3)
+	output, err := testtransaction([]TxOp{
+		{Op: "put", Key: keys[0], Relationship: "rel", Value: "a"},
+		{Op: "put", Key: keys[1], Relationship: "rel", Value: "b"},
+	})
+	if err != nil || !output.Result || len(output.Versions) != 2 {
+		t.Fatal("the transaction was not committed:",output,err)
+	}
+	testreleased(t)
+
+	k := datakey{keys[2],"rel"}
+	owner := ringsuccessor(DataHash(k.key,k.relation))
+	servermap[owner].locks[k] = "other"
+	servermap[owner].prepared["other"] = []walentry{}
+	if _,err := testtransaction([]TxOp{{Op: "put", Key: keys[0], Relationship: "rel", Value: "c"},{Op: "put", Key: k.key, Relationship: k.relation, Value: "c"}}); err == nil || !strings.Contains(err.Error(),"locked") {
+		t.Fatal("the transaction changed a locked triplet:",err)
+	}
+	if value,_ := testlookup(t,keys[0],"rel"); value != "a" {
+		t.Fatal("the aborted transaction changed",keys[0],"to",value)
+	}
+	var insert JsonResultInsert
+	if err := new(Dict3).Insert(&JsonMessage{Params: []interface{}{k.key,k.relation,"c"}, Portno: 7000},&insert); err == nil {
+		t.Fatal("the insert changed a locked triplet")
+	}
+	ringlock.Lock()
+	aborttransaction(7000,"other",[]int{owner})
+	ringlock.Unlock()
+	testreleased(t)
+	testinsert(t,k.key,k.relation,"c")
+}
+
+/*This test checks that a transaction that is committed in the coordinator log but applied on only some of its participants is
+*completed on the others before the next transaction, and that its locks are released.*/
+func TestTransactionRecovery(t *testing.T) {
+	testring(t,4)
+	keys := testspread(t,3)
+	testinsert(t,keys[1],"rel","old")
+	ops := []TxOp{
+		{Op: "put", Key: keys[0], Relationship: "rel", Value: "new"},
+		{Op: "delete", Key: keys[1], Relationship: "rel"},
+	}
+	ringlock.Lock()
+	writes := []walentry{}
+	ports := []int{}
+	for i,op := range ops {
+		port := ringsuccessor(DataHash(op.Key,op.Relationship))
+		staged, err := prepare(port,"crashed",ops,[]int{i},false,"")
+		if err != nil {
+			ringlock.Unlock()
+			t.Fatal(err)
+		}
+		writes = append(writes,staged...)
+		ports = append(ports,port)
+	}
+	if err := writetxlog(txlogentry{"crashed",7000,"commit",writes}); err != nil {
+		ringlock.Unlock()
+		t.Fatal(err)
+	}
+	//The coordinator crashes after the first participant has committed.
+	err := commit(ports[0],"crashed",writes[:1])
+	ringlock.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _,ok := testlookup(t,keys[1],"rel"); !ok {
+		t.Fatal("the second participant committed before the recovery")
+	}
+
+	if _,err := testtransaction([]TxOp{{Op: "put", Key: keys[2], Relationship: "rel", Value: "next"}}); err != nil {
+		t.Fatal(err)
+	}
+	if value,_ := testlookup(t,keys[0],"rel"); value != "new" {
+		t.Fatal("the committed put was lost:",value)
+	}
+	if _,ok := testlookup(t,keys[1],"rel"); ok {
+		t.Fatal("the committed delete was not completed")
+	}
+	if value,_ := testlookup(t,keys[2],"rel"); value != "next" {
+		t.Fatal("the next transaction was not committed:",value)
+	}
+	testreleased(t)
+}
@@ -0,0 +1,42 @@
+/*Package tabfile holds the escaping of the tab separated format of the DICT3 file, which is shared by the server
+*that writes the DICT3 file and the client that exports and imports the ring in this format.*/
+package tabfile
+
+import (
+	"strings"
+)
+
+/*The first line of a tab separated file whose fields are escaped. Files without it were written before the fields
+*were escaped and are read as they are, so that a backslash in them is not changed.*/
+const Header = "#DICT3\tescaped"
+
+/*This function is used to escape a field of the tab separated format so that tabs and new lines in the value
+*do not split the line. Backslashes, tabs, carriage returns and new lines are written as \\, \t, \r and \n.
+*input: The field to escape.
+*output: The escaped field.*/
+func Escape(field string) string {
+	return strings.NewReplacer("\\","\\\\","\t","\\t","\r","\\r","\n","\\n").Replace(field)
+}
+
+/*This function is used to reverse the escaping of a field of the tab separated format. A backslash that does
+*not start a known escape sequence is kept as it is.
+*input: The escaped field.
+*output: The original field.*/
+func Unescape(field string) string {
+	if !strings.Contains(field,"\\") {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+1 < len(field) {
+			switch field[i+1] {
+			case '\\': b.WriteByte('\\'); i++; continue
+			case 't': b.WriteByte('\t'); i++; continue
+			case 'r': b.WriteByte('\r'); i++; continue
+			case 'n': b.WriteByte('\n'); i++; continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package tabfile
+
+import (
+	"strings"
+	"testing"
+)
+
+/*This test checks that every field comes back unchanged after it is escaped and that an escaped field never holds a tab or a line break.*/
+func TestRoundTrip(t *testing.T) {
+	fields := []string{
+		"",
+		"plain",
+		"a\tb",
+		"line\nbreak",
+		"windows\r\nline",
+		"back\\slash",
+		"\\t is not a tab",
+		"ends with \\",
+		"\\\\n",
+		"\t\n\r\\",
+		Header,
+	}
+	for _,field := range fields {
+		escaped := Escape(field)
+		if strings.ContainsAny(escaped,"\t\r\n") {
+			t.Fatalf("the escaped field %q holds a tab or a line break: %q",field,escaped)
+		}
+		if back := Unescape(escaped); back != field {
+			t.Fatalf("the field %q came back as %q",field,back)
+		}
+	}
+	line := strings.Join([]string{Escape("key\t1"),Escape("rel"),Escape("a\tvalue\n")},"\t")
+	if parts := strings.Split(line,"\t"); len(parts) != 3 || Unescape(parts[0]) != "key\t1" || Unescape(parts[2]) != "a\tvalue\n" {
+		t.Fatalf("the escaped line was split into %q",parts)
+	}
+}
+
+/*This test checks that a backslash that does not start an escape sequence is kept, as files without the header are not escaped.*/
+func TestUnescapeUnknown(t *testing.T) {
+	cases := map[string]string{
+		"C:\\data\\dict3": "C:\\data\\dict3",
+		"trailing\\": "trailing\\",
+		"\\x41": "\\x41",
+		"no escapes": "no escapes",
+		"\\\\t": "\\t",
+	}
+	for field,want := range cases {
+		if got := Unescape(field); got != want {
+			t.Fatalf("%q was unescaped to %q instead of %q",field,got,want)
+		}
+	}
+}